@@ -0,0 +1,49 @@
+package goretriever
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Analyzer loads and type checks packages once, all queries on it
+// share the same FileSet and type information.
+type Analyzer struct {
+	ProjectPath string
+	Fset        *token.FileSet
+	Packages    []*packages.Package
+	Diagnostics *Diagnostics
+
+	opts       *options
+	interfaces map[string]bool
+	concrete   []types.Type
+}
+
+// NewAnalyzer loads the packages matching packagePattern in projectPath.
+func NewAnalyzer(projectPath string, packagePattern []string, envs []string, opts ...Option) (*Analyzer, error) {
+	var (
+		o    = newOptions(opts)
+		fset = token.NewFileSet()
+		d    = &Diagnostics{}
+	)
+
+	if o.diagnostics == nil {
+		o.diagnostics = d
+	} else {
+		d = o.diagnostics
+	}
+
+	pkgs, err := getPkgs(projectPath, packagePattern, fset, envs, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Analyzer{
+		ProjectPath: projectPath,
+		Fset:        fset,
+		Packages:    pkgs,
+		Diagnostics: d,
+		opts:        o,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package goretriever
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"kv/kv.go": `package kv
+
+type Store interface {
+	Get(key string) string
+}
+
+type Map map[string]string
+
+func (m Map) Get(key string) string { return m[key] }
+
+func init() {}
+
+func Lookup(s Store, key string) string {
+	return s.Get(key)
+}
+`,
+	})
+
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Packages) != 1 || a.Diagnostics.HasErrors() {
+		t.Fatalf("loaded %d packages with diagnostics %v", len(a.Packages), a.Diagnostics)
+	}
+
+	interfaces := a.Interfaces()
+	if !interfaces["example.com/m/kv.Store"] || len(interfaces) != 1 {
+		t.Errorf("interfaces are %v", interfaces)
+	}
+	interfaces["cached"] = true
+	if !a.Interfaces()["cached"] {
+		t.Error("interfaces are not computed once")
+	}
+
+	var decls []string
+	for fd := range a.FuncDecls() {
+		decls = append(decls, fd.Id)
+	}
+	sort.Strings(decls)
+	want := []string{
+		"(example.com/m/kv.Map).Get",
+		"example.com/m/kv.Lookup",
+		"example.com/m/kv.init#1",
+	}
+	if len(decls) != len(want) {
+		t.Fatalf("declarations are %v, want %v", decls, want)
+	}
+	for i := range want {
+		if decls[i] != want[i] {
+			t.Errorf("declarations are %v, want %v", decls, want)
+			break
+		}
+	}
+
+	// the graph shares the FileSet of the analyzer
+	lookup := a.BuildCallGraph().Node("example.com/m/kv.Lookup")
+	if lookup == nil {
+		t.Fatal("no node for Lookup")
+	}
+	if file := a.Fset.File(a.Packages[0].Syntax[0].Pos()).Name(); lookup.Pos.Filename != file {
+		t.Errorf("Lookup is in %s, want %s", lookup.Pos.Filename, file)
+	}
+
+	found, err := FindFuncDecls(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != len(want) {
+		t.Errorf("FindFuncDecls found %d declarations, want %d", len(found), len(want))
+	}
+}
@@ -0,0 +1,230 @@
+package goretriever
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// CallNode is a function of the call graph. There is exactly one node
+// per function, identified by its canonical id.
+type CallNode struct {
+	// ID is the canonical id, eg. pkg.Func or (*pkg.Type).Method.
+	ID        string
+	Pkg       string
+	Recv      string
+	Name      string
+	Signature string
+	Code      string
+	Pos       token.Position
+	// Func is nil for functions without a types.Func.
+	Func *types.Func
+	// Parent is the enclosing function of a closure.
+	Parent *CallNode
+	// Synthetic is set for nodes which are not declared in the source,
+	// eg. the package initializer.
+	Synthetic bool
+	// Metrics is nil for functions without syntax.
+	Metrics *Metrics
+
+	Out []*CallEdge
+	In  []*CallEdge
+}
+
+// CallEdge links a caller to one of its callees.
+type CallEdge struct {
+	Caller *CallNode
+	Callee *CallNode
+	// Dynamic is set for calls resolved at run time, eg. from an
+	// interface method to one of its implementations.
+	Dynamic bool
+	// Sites lists where the calls are made, in source order. Dynamic
+	// edges of interface methods have no sites, the calls are made
+	// on the edges to the interface method.
+	Sites []*CallSite
+}
+
+// CallGraph is a call graph with interned nodes and de-duplicated edges.
+type CallGraph struct {
+	nodes map[string]*CallNode
+	funcs map[*types.Func]*CallNode
+	edges map[[2]*CallNode]*CallEdge
+}
+
+func NewCallGraph() *CallGraph {
+	return &CallGraph{
+		nodes: make(map[string]*CallNode),
+		funcs: make(map[*types.Func]*CallNode),
+		edges: make(map[[2]*CallNode]*CallEdge),
+	}
+}
+
+// FuncID returns the canonical id of fn. Instantiations of generic
+// functions share the id of their origin.
+func FuncID(fn *types.Func) string {
+	return fn.Origin().FullName()
+}
+
+// Descriptor converts the node into a FuncDescriptor.
+func (n *CallNode) Descriptor() *FuncDescriptor {
+	return &FuncDescriptor{
+		Id:       n.ID,
+		DeclType: n.Signature,
+		Code:     n.Code,
+	}
+}
+
+// QualifiedName returns pkg.Recv.Name, or pkg.Name for functions.
+func (n *CallNode) QualifiedName() string {
+	if n.Recv != "" {
+		return n.Pkg + "." + n.Recv + "." + n.Name
+	}
+	return n.Pkg + "." + n.Name
+}
+
+// Callees returns the functions called by n.
+func (n *CallNode) Callees() []*CallNode {
+	callees := make([]*CallNode, len(n.Out))
+	for i, e := range n.Out {
+		callees[i] = e.Callee
+	}
+	return callees
+}
+
+// Callers returns the functions calling n.
+func (n *CallNode) Callers() []*CallNode {
+	callers := make([]*CallNode, len(n.In))
+	for i, e := range n.In {
+		callers[i] = e.Caller
+	}
+	return callers
+}
+
+// Node returns the node with the given id, or nil.
+func (g *CallGraph) Node(id string) *CallNode {
+	return g.nodes[id]
+}
+
+// NodeOf returns the node of fn, or nil.
+func (g *CallGraph) NodeOf(fn *types.Func) *CallNode {
+	if n, ok := g.funcs[fn]; ok {
+		return n
+	}
+	return g.nodes[FuncID(fn)]
+}
+
+// Nodes returns all nodes ordered by id.
+func (g *CallGraph) Nodes() []*CallNode {
+	nodes := make([]*CallNode, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// Edges returns all edges ordered by caller and callee id.
+func (g *CallGraph) Edges() []*CallEdge {
+	edges := make([]*CallEdge, 0, len(g.edges))
+	for _, e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Caller.ID != edges[j].Caller.ID {
+			return edges[i].Caller.ID < edges[j].Caller.ID
+		}
+		return edges[i].Callee.ID < edges[j].Callee.ID
+	})
+	return edges
+}
+
+// Len returns the number of nodes.
+func (g *CallGraph) Len() int {
+	return len(g.nodes)
+}
+
+// AddNode returns the node with the given id, creating it when missing.
+func (g *CallGraph) AddNode(id string) *CallNode {
+	if n, ok := g.nodes[id]; ok {
+		return n
+	}
+	n := &CallNode{ID: id, Name: id}
+	g.nodes[id] = n
+	return n
+}
+
+// AddFunc returns the node of fn, creating it when missing.
+func (g *CallGraph) AddFunc(fn *types.Func) *CallNode {
+	if n, ok := g.funcs[fn]; ok {
+		return n
+	}
+
+	fn = fn.Origin()
+	return g.addFuncAs(fn, FuncID(fn))
+}
+
+// addFuncAs interns fn under id, for functions which do not have
+// a unique canonical id such as init functions.
+func (g *CallGraph) addFuncAs(fn *types.Func, id string) *CallNode {
+	n, ok := g.nodes[id]
+	if !ok {
+		n = &CallNode{
+			ID:        id,
+			Name:      fn.Name(),
+			Signature: fn.Type().String(),
+		}
+		if fn.Pkg() != nil {
+			n.Pkg = fn.Pkg().Path()
+		}
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			n.Recv = recvTypeName(recv.Type())
+		}
+		g.nodes[n.ID] = n
+	}
+	if n.Func == nil {
+		n.Func = fn
+	}
+	g.funcs[fn] = n
+	return n
+}
+
+// recvTypeName returns the name of the named type of a receiver.
+func recvTypeName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	switch t := t.(type) {
+	case *types.Named:
+		return t.Obj().Name()
+	case *types.Alias:
+		return t.Obj().Name()
+	}
+	return types.TypeString(t, func(*types.Package) string { return "" })
+}
+
+// AddEdge links caller to callee, returning the existing edge if any.
+// An existing dynamic edge becomes static.
+func (g *CallGraph) AddEdge(caller, callee *CallNode) *CallEdge {
+	e := g.addEdge(caller, callee, false)
+	e.Dynamic = false
+	return e
+}
+
+// AddDynamicEdge links caller to callee with a dynamic edge, returning
+// the existing edge if any.
+func (g *CallGraph) AddDynamicEdge(caller, callee *CallNode) *CallEdge {
+	return g.addEdge(caller, callee, true)
+}
+
+func (g *CallGraph) addEdge(caller, callee *CallNode, dynamic bool) *CallEdge {
+	key := [2]*CallNode{caller, callee}
+	if e, ok := g.edges[key]; ok {
+		return e
+	}
+
+	e := &CallEdge{Caller: caller, Callee: callee, Dynamic: dynamic}
+	g.edges[key] = e
+	caller.Out = append(caller.Out, e)
+	callee.In = append(callee.In, e)
+	return e
+}
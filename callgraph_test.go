@@ -0,0 +1,84 @@
+package goretriever
+
+import (
+	"go/types"
+	"testing"
+)
+
+func TestCallGraphCanonicalNodes(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"list/list.go": `package list
+
+func Map[T any](xs []T, f func(T) T) []T {
+	for i := range xs {
+		xs[i] = f(xs[i])
+	}
+	return xs
+}
+
+func double(x int) int { return 2 * x }
+
+func Ints(xs []int) []int {
+	Map(xs, double)
+	return Map(xs, double)
+}
+
+func Strings(xs []string) []string {
+	return Map(xs, func(s string) string { return s + s })
+}
+`,
+	})
+
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := a.BuildCallGraph()
+
+	m := graph.Node("example.com/m/list.Map")
+	if m == nil {
+		t.Fatal("no node for Map")
+	}
+	// both instantiations call the same node, twice from Ints
+	callers := make(map[string]int)
+	for _, e := range m.In {
+		callers[e.Caller.ID] += len(e.Sites)
+	}
+	if len(callers) != 2 || callers["example.com/m/list.Ints"] != 2 || callers["example.com/m/list.Strings"] != 1 {
+		t.Errorf("callers of Map are %v", callers)
+	}
+
+	fn := a.Packages[0].Types.Scope().Lookup("Map").(*types.Func)
+	if graph.NodeOf(fn) != m {
+		t.Error("NodeOf(Map) is not the node of Map")
+	}
+	if n := graph.Len(); n != len(graph.Nodes()) {
+		t.Errorf("Len is %d for %d nodes", n, len(graph.Nodes()))
+	}
+}
+
+func TestCallGraphEdges(t *testing.T) {
+	g := NewCallGraph()
+	a, b := g.AddNode("a"), g.AddNode("b")
+	if g.AddNode("a") != a {
+		t.Error("AddNode created a second node for a")
+	}
+
+	dynamic := g.AddDynamicEdge(a, b)
+	if !dynamic.Dynamic {
+		t.Error("dynamic edge is static")
+	}
+	if static := g.AddEdge(a, b); static != dynamic || static.Dynamic {
+		t.Error("a static call does not turn the dynamic edge static")
+	}
+	if g.AddDynamicEdge(a, b).Dynamic {
+		t.Error("a dynamic call turned the static edge dynamic")
+	}
+
+	if len(a.Out) != 1 || len(b.In) != 1 || len(g.Edges()) != 1 {
+		t.Errorf("got %d out, %d in and %d edges, want 1", len(a.Out), len(b.In), len(g.Edges()))
+	}
+	if callers := b.Callers(); len(callers) != 1 || callers[0] != a {
+		t.Errorf("callers of b are %v", callers)
+	}
+}
@@ -1,8 +1,8 @@
 package goretriever
 
 import (
+	"bytes"
 	"errors"
-	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 // FuncDescriptor stores an information about
@@ -33,55 +34,171 @@ const LoadMode packages.LoadMode = packages.NeedName |
 	packages.NeedTypesInfo |
 	packages.NeedFiles
 
-func getPkgs(projectPath string, packagePattern []string, fset *token.FileSet, envs []string) ([]*packages.Package, error) {
+// ssaLoadMode type checks the dependencies from source too, for the
+// call graph algorithms working on the SSA form.
+const ssaLoadMode = LoadMode | packages.NeedImports | packages.NeedDeps
+
+func getPkgs(projectPath string, packagePattern []string, fset *token.FileSet, envs []string, opts *options) ([]*packages.Package, error) {
+	mode := LoadMode
+	if opts.algorithm != AlgorithmAST {
+		mode = ssaLoadMode
+	}
+
 	cfg := &packages.Config{
-		Fset: fset,
-		Mode: LoadMode,
-		Dir:  projectPath,
-		Env:  envs,
+		Fset:  fset,
+		Mode:  mode,
+		Dir:   projectPath,
+		Env:   envs,
+		Tests: opts.tests,
+	}
+
+	pkgs, err := packages.Load(cfg, packagePattern...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.tests {
+		pkgs = testVariants(pkgs)
 	}
 
-	return packages.Load(cfg, packagePattern...)
+	diagnostics := newDiagnostics(pkgs)
+	if opts.diagnostics != nil {
+		*opts.diagnostics = *diagnostics
+	}
+	if opts.errorPolicy == FailOnError {
+		if roots := rootDiagnostics(pkgs); roots.HasErrors() {
+			return nil, &LoadError{Diagnostics: roots}
+		}
+	}
+	return pkgs, nil
+}
+
+// testVariants keeps the test variant of packages which have one, eg.
+// "p [p.test]" replaces "p", and drops the generated test mains.
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	tested := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ID != pkg.PkgPath && strings.HasPrefix(pkg.ID, pkg.PkgPath+" [") {
+			tested[pkg.PkgPath] = true
+		}
+	}
+
+	var variants []*packages.Package
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") || (pkg.ID == pkg.PkgPath && tested[pkg.PkgPath]) {
+			continue
+		}
+		variants = append(variants, pkg)
+	}
+	return variants
 }
 
 // FindRootFunctions looks for all root functions eg. entry points.
 // Currently an entry point is a function that contains call of function
-// passed as functionLabel paramaterer.
-func FindRootFunctions(projectPath string, packagePattern []string, functionLabel string, envs []string) []*FuncDescriptor {
-	var (
-		fset          = token.NewFileSet()
-		pkgs, _       = getPkgs(projectPath, packagePattern, fset, envs)
-		currentFun    *FuncDescriptor
-		rootFunctions []*FuncDescriptor
-	)
+// passed as functionLabel paramaterer, see MatchFunc for its format.
+func FindRootFunctions(projectPath string, packagePattern []string, functionLabel string, envs []string, opts ...Option) ([]*FuncDescriptor, error) {
+	return FindRootFunctionsFunc(projectPath, packagePattern, MatchFunc(functionLabel), envs, opts...)
+}
 
-	for _, pkg := range pkgs {
-		for _, node := range pkg.Syntax {
-			ast.Inspect(node, func(n ast.Node) bool {
-				switch xNode := n.(type) {
-				case *ast.CallExpr:
-					selector, ok := xNode.Fun.(*ast.SelectorExpr)
-					if !ok {
-						break
-					}
-					if selector.Sel.Name == functionLabel {
-						rootFunctions = append(rootFunctions, currentFun)
-					}
+// FindRootFunctionsFunc looks for all functions containing a call of a
+// function for which match returns true.
+func FindRootFunctionsFunc(projectPath string, packagePattern []string, match func(*types.Func) bool, envs []string, opts ...Option) ([]*FuncDescriptor, error) {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a.RootFunctionsFunc(match), nil
+}
 
-				case *ast.FuncDecl:
-					def := pkg.TypesInfo.Defs[xNode.Name]
-					if def == nil {
-						break
-					}
+// FuncQualifiedName returns pkg.Recv.Name for methods, pkg.Name for
+// functions, where pkg is the package path and Recv the name of the
+// receiver type without pointer.
+func FuncQualifiedName(fn *types.Func) string {
+	var pkg string
+	if fn.Pkg() != nil {
+		pkg = fn.Pkg().Path() + "."
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		return pkg + recvTypeName(recv.Type()) + "." + fn.Name()
+	}
+	return pkg + fn.Name()
+}
 
-					currentFun = &FuncDescriptor{
-						Id:       def.Pkg().Path() + "." + def.Name(),
-						DeclType: def.Type().String(),
-					}
+// MatchFunc returns a predicate matching the functions named by label.
+// A qualified label like go.opentelemetry.io/otel/trace.Tracer.Start or
+// net/http.HandleFunc, see FuncQualifiedName, matches one function or
+// method, including the methods of interfaces. A label without any dot
+// matches all functions and methods with this name.
+func MatchFunc(label string) func(*types.Func) bool {
+	if !strings.Contains(label, ".") {
+		return func(fn *types.Func) bool {
+			return fn.Name() == label
+		}
+	}
+	return func(fn *types.Func) bool {
+		return FuncQualifiedName(fn) == label
+	}
+}
+
+// RootFunctions looks for all functions containing a call of the function
+// passed as functionLabel parameter, see MatchFunc for its format.
+func (a *Analyzer) RootFunctions(functionLabel string) []*FuncDescriptor {
+	return a.RootFunctionsFunc(MatchFunc(functionLabel))
+}
+
+// RootFunctionsFunc looks for all functions containing a call of a
+// function for which match returns true. Calls are resolved with type
+// information; calls made by closures count for their enclosing function
+// and calls made while initializing package variables are ignored.
+func (a *Analyzer) RootFunctionsFunc(match func(*types.Func) bool) []*FuncDescriptor {
+	var rootFunctions []*FuncDescriptor
+
+	for _, pkg := range a.Packages {
+		var (
+			w     = newFuncWalker(pkg)
+			decls = make(map[string]*types.Func)
+			found = make(map[string]bool)
+			// enclosing maps closures to their declared function
+			enclosing = make(map[string]string)
+			order     []string
+		)
+		w.enter = func(node ast.Node, fn, parent string) {
+			switch node := node.(type) {
+			case *ast.FuncDecl:
+				if node.Body != nil {
+					decls[fn] = pkg.TypesInfo.Defs[node.Name].(*types.Func)
+					enclosing[fn] = fn
+					order = append(order, fn)
+				}
+			case *ast.FuncLit:
+				if decl, ok := enclosing[parent]; ok {
+					enclosing[fn] = decl
+				}
+			}
+		}
+		for _, file := range pkg.Syntax {
+			w.walkFile(file, func(n ast.Node, stack []ast.Node, fn string) {
+				decl, ok := enclosing[fn]
+				if !ok || found[decl] {
+					return
+				}
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return
+				}
+				if callee, ok := typeutil.Callee(pkg.TypesInfo, call).(*types.Func); ok && match(callee.Origin()) {
+					found[decl] = true
 				}
-				return true
 			})
 		}
+
+		for _, id := range order {
+			if found[id] {
+				rootFunctions = append(rootFunctions, &FuncDescriptor{
+					Id:       id,
+					DeclType: decls[id].Type().String(),
+				})
+			}
+		}
 	}
 	return rootFunctions
 }
@@ -142,6 +259,10 @@ func GetMostInnerAstIdent(inSel *ast.SelectorExpr) (*ast.Ident, error) {
 
 // GetPkgPathFromRecvInterface builds package path taking
 // receiver interface into account.
+//
+// Deprecated: methods are no longer named after the interfaces they
+// implement, use FuncID. Interface calls are linked to implementations
+// by BuildCallGraph.
 func GetPkgPathFromRecvInterface(pkg *packages.Package,
 	pkgs []*packages.Package, funDeclNode *ast.FuncDecl, interfaces map[string]bool,
 ) string {
@@ -178,6 +299,8 @@ func GetPkgPathFromRecvInterface(pkg *packages.Package,
 }
 
 // GetPkgPathFromFunctionRecv build package path taking function receiver parameters.
+//
+// Deprecated: use FuncID.
 func GetPkgPathFromFunctionRecv(pkg *packages.Package,
 	pkgs []*packages.Package, funDeclNode *ast.FuncDecl, interfaces map[string]bool) string {
 	pkgPath := GetPkgPathFromRecvInterface(pkg, pkgs, funDeclNode, interfaces)
@@ -262,6 +385,8 @@ func GetPkgNameFromDefsTable(pkg *packages.Package, ident *ast.Ident) string {
 
 // GetPkgPathForFunction builds package path, delegates work to
 // other helper functions defined above.
+//
+// Deprecated: use FuncID.
 func GetPkgPathForFunction(pkg *packages.Package,
 	pkgs []*packages.Package, funDecl *ast.FuncDecl, interfaces map[string]bool) string {
 	if funDecl.Recv != nil {
@@ -270,177 +395,219 @@ func GetPkgPathForFunction(pkg *packages.Package,
 	return GetPkgNameFromDefsTable(pkg, funDecl.Name)
 }
 
-func getTypesObject(pkg *packages.Package, id *ast.Ident) types.Object {
-	return pkg.TypesInfo.Uses[id]
+// BuildCallGraph builds the call graph of the packages matching
+// packagePattern.
+func BuildCallGraph(projectPath string, packagePattern []string, envs []string, opts ...Option) (*CallGraph, error) {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a.BuildCallGraph(), nil
 }
 
-func newFuncDescFromCallIdent(pkg *packages.Package, f ast.Expr) *FuncDescriptor {
-	id, ok := f.(*ast.Ident)
-	if !ok {
-		return nil
+// BuildCallGraph builds the call graph of the loaded packages with the
+// algorithm set by WithAlgorithm. Both callees and callers of every node
+// are recorded.
+//
+// Closures are nodes of their own, named after their parent like
+// parent$1, and linked from it. Calls made while initializing package
+// level variables are attributed to the synthetic pkg.init node, which
+// also calls the init functions of the package, named pkg.init#1...
+//
+// Calls through an interface go to the interface method, which calls
+// every implementation of the loaded packages through dynamic edges.
+func (a *Analyzer) BuildCallGraph() *CallGraph {
+	var alg Algorithm
+	if a.opts != nil {
+		alg = a.opts.algorithm
 	}
+	return a.BuildCallGraphWith(alg)
+}
 
-	obj := getTypesObject(pkg, id)
-	if obj == nil {
-		return nil
+// BuildCallGraphWith builds the call graph of the loaded packages with alg.
+// The dependencies are only loaded from source for the SSA algorithms when
+// set by WithAlgorithm, otherwise calls resolved into them may be missing.
+func (a *Analyzer) BuildCallGraphWith(alg Algorithm) *CallGraph {
+	var callGraph *CallGraph
+	if alg != AlgorithmAST {
+		callGraph = a.buildSSACallGraph(alg)
+	} else {
+		callGraph = a.buildASTCallGraph()
 	}
+	callGraph.updateFanMetrics()
+	return callGraph
+}
 
-	var pkgPath = GetPkgNameFromUsesTable(pkg, id)
-	// fmt.Println("\t\t\tFuncCall:", funId, pkg.TypesInfo.Uses[id].Type().String(),
-	// 	" @called : ",
-	// 	fset.File(node.Pos()).Name())
-	return &FuncDescriptor{
-		Id:       pkgPath + "." + obj.Name(),
-		DeclType: obj.Type().String(),
-		Code:     obj.String(),
+func (a *Analyzer) buildASTCallGraph() *CallGraph {
+	callGraph := NewCallGraph()
+
+	for _, pkg := range a.Packages {
+		b := &callGraphBuilder{
+			fset:      a.Fset,
+			pkg:       pkg,
+			callGraph: callGraph,
+			litCalls:  make(map[*ast.FuncLit]*CallSite),
+		}
+		w := newFuncWalker(pkg)
+		w.enter = b.enter
+		for _, file := range pkg.Syntax {
+			b.content, _ = os.ReadFile(a.Fset.File(file.Pos()).Name())
+			w.walkFile(file, b.visit)
+		}
 	}
+	a.resolveDynamicCalls(callGraph)
+	return callGraph
 }
 
-func newFuncDescFromCallSelector(pkg *packages.Package, f ast.Expr) *FuncDescriptor {
-	sel, ok := f.(*ast.SelectorExpr)
-	if !ok {
-		return nil
-	}
+// callGraphBuilder adds the functions and calls of a package to the
+// call graph, functions are named by funcWalker.
+type callGraphBuilder struct {
+	fset      *token.FileSet
+	pkg       *packages.Package
+	callGraph *CallGraph
+	content   []byte
+	initNode  *CallNode
+	litCalls  map[*ast.FuncLit]*CallSite
+}
 
-	obj := getTypesObject(pkg, sel.Sel)
-	if obj == nil {
-		return nil
+// packageInit returns the synthetic initializer node of the package.
+func (b *callGraphBuilder) packageInit() *CallNode {
+	if b.initNode == nil {
+		b.initNode = b.callGraph.AddNode(b.pkg.PkgPath + ".init")
+		b.initNode.Pkg = b.pkg.PkgPath
+		b.initNode.Name = "init"
+		b.initNode.Signature = "func()"
+		b.initNode.Synthetic = true
 	}
+	return b.initNode
+}
 
-	pkgPath := GetPkgNameFromUsesTable(pkg, sel.Sel)
-	if sel.X != nil {
-		pkgPath = GetSelectorPkgPath(sel, pkg, pkgPath)
+// node returns the node of the function fn named by funcWalker.
+func (b *callGraphBuilder) node(fn string) *CallNode {
+	if fn == b.pkg.PkgPath+".init" {
+		return b.packageInit()
 	}
+	return b.callGraph.Node(fn)
+}
 
-	// fmt.Println("\t\t\tFuncCall via selector:", funId, pkg.TypesInfo.Uses[sel.Sel].Type().String(),
-	// 	" @called : ",
-	// 	fset.File(node.Pos()).Name())
-	return &FuncDescriptor{
-		Id:       pkgPath + "." + obj.Name(),
-		DeclType: obj.Type().String(),
-		Code:     obj.String(),
+func (b *callGraphBuilder) code(beg, end int) string {
+	code, err := parseCode(bytes.NewReader(b.content), int64(beg), int64(end))
+	if err != nil {
+		return ""
 	}
+	return code
 }
 
-// BuildCallGraph builds an information about flow graph
-// in the following form child->parent.
-func BuildCallGraph(
-	projectPath string,
-	packagePattern []string,
-	funcDecls map[*FuncDescriptor]bool,
-	interfaces map[string]bool,
-	envs []string,
-) (map[*FuncDescriptor][]*FuncDescriptor, map[*FuncDescriptor][]*FuncDescriptor) {
-	var (
-		fset              = token.NewFileSet()
-		pkgs, _           = getPkgs(projectPath, packagePattern, fset, envs)
-		currentFun        *FuncDescriptor
-		backwardCallGraph = make(map[*FuncDescriptor][]*FuncDescriptor)
-		forwardCallGraph  = make(map[*FuncDescriptor][]*FuncDescriptor)
-	)
-
-	for _, pkg := range pkgs {
-
-		for _, node := range pkg.Syntax {
-			// fmt.Println("\t\t", fset.File(node.Pos()).Name())
-			ast.Inspect(node, func(n ast.Node) bool {
-				switch xNode := n.(type) {
-				case *ast.CallExpr:
-
-					fun := newFuncDescFromCallIdent(pkg, xNode.Fun)
-					if fun == nil {
-						fun = newFuncDescFromCallSelector(pkg, xNode.Fun)
-					}
+// enter adds the node of a declared function or of a closure.
+func (b *callGraphBuilder) enter(node ast.Node, fn, parent string) {
+	switch xNode := node.(type) {
+	case *ast.FuncDecl:
+		def := b.pkg.TypesInfo.Defs[xNode.Name].(*types.Func)
+
+		var fun *CallNode
+		if xNode.Recv == nil && xNode.Name.Name == "init" {
+			// init functions can not be referenced and may be
+			// declared many times, they are numbered like go/ssa does
+			fun = b.callGraph.addFuncAs(def, fn)
+			b.callGraph.AddEdge(b.packageInit(), fun)
+		} else {
+			fun = b.callGraph.AddFunc(def)
+		}
+		fun.Pos = b.fset.Position(xNode.Pos())
+		fun.Metrics = newMetrics(b.fset, xNode, xNode.Type, xNode.Body)
 
-					if fun != nil {
-						if !funcContains(backwardCallGraph[fun], currentFun) {
-							if funcDecls[fun] {
-								backwardCallGraph[fun] = append(backwardCallGraph[fun], currentFun)
-							}
-						}
-						if !funcContains(forwardCallGraph[currentFun], fun) {
-							forwardCallGraph[currentFun] = append(forwardCallGraph[currentFun], fun)
-						}
-					}
+		beg, end, err := getFuncDeclOffset(xNode, b.fset)
+		if err == nil {
+			fun.Code = b.code(beg, end)
+		}
 
-				case *ast.FuncDecl:
-					def, ok := pkg.TypesInfo.Defs[xNode.Name]
-					if ok {
-						var pkgPath = GetPkgPathForFunction(pkg, pkgs, xNode, interfaces)
-						var funId = pkgPath + "." + def.Name()
-						var fun = &FuncDescriptor{
-							Id:       funId,
-							DeclType: def.Type().String(),
-						}
-
-						beg, end, err := getFuncDeclOffset(xNode, fset)
-						if err == nil {
-							file, err := os.OpenFile(fset.File(node.Pos()).Name(), os.O_RDONLY, os.ModePerm)
-							if err == nil {
-								code, err := parseCode(file, int64(beg), int64(end))
-								if err == nil {
-									fun.Code = code
-								}
-								file.Close()
-							}
-						}
-
-						funcDecls[fun] = true
-						currentFun = fun
-
-						// fmt.Println("\t\t\tFuncDecl:", funId, def.Type().String())
-					}
-				}
-				return true
-			})
+	case *ast.FuncLit:
+		enclosing := b.node(parent)
+		closure := b.callGraph.AddNode(fn)
+		closure.Pkg = enclosing.Pkg
+		closure.Recv = enclosing.Recv
+		closure.Name = enclosing.Name + strings.TrimPrefix(fn, enclosing.ID)
+		closure.Parent = enclosing
+		closure.Pos = b.fset.Position(xNode.Pos())
+		if t := b.pkg.TypesInfo.TypeOf(xNode); t != nil {
+			closure.Signature = t.String()
 		}
+		closure.Code = b.code(b.fset.Position(xNode.Pos()).Offset, b.fset.Position(xNode.End()).Offset)
+		closure.Metrics = newMetrics(b.fset, xNode, xNode.Type, xNode.Body)
+
+		// closures called where they are declared get the call site
+		b.callGraph.AddEdge(enclosing, closure).addSite(b.litCalls[xNode])
 	}
-	return backwardCallGraph, forwardCallGraph
 }
 
-// FindFuncDecls looks for all function declarations.
-func FindFuncDecls(projectPath string, packagePattern []string, interfaces map[string]bool, envs []string) map[*FuncDescriptor]bool {
-	var (
-		fset      = token.NewFileSet()
-		pkgs, _   = getPkgs(projectPath, packagePattern, fset, envs)
-		funcDecls = make(map[*FuncDescriptor]bool)
-	)
-
-	for _, pkg := range pkgs {
-		for _, node := range pkg.Syntax {
-			fmt.Println("\t\t", fset.File(node.Pos()).Name())
-
-			ast.Inspect(node, func(n ast.Node) bool {
-				funDeclNode, ok := n.(*ast.FuncDecl)
-				if !ok {
-					return true
-				}
+// visit attributes the calls to fn, calls made while initializing
+// package variables go to the package initializer.
+func (b *callGraphBuilder) visit(n ast.Node, stack []ast.Node, fn string) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || fn == "" {
+		return
+	}
 
-				var pkgPath = GetPkgPathForFunction(pkg, pkgs, funDeclNode, interfaces)
+	site := newCallSite(stack, call, b.pkg.TypesInfo, b.fset, b.content)
+	if lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit); ok {
+		b.litCalls[lit] = site
+	}
 
-				def, ok := pkg.TypesInfo.Defs[funDeclNode.Name]
-				if !ok {
-					return true
-				}
+	callee, ok := typeutil.Callee(b.pkg.TypesInfo, call).(*types.Func)
+	if !ok {
+		return
+	}
+	b.callGraph.AddEdge(b.node(fn), b.callGraph.AddFunc(callee)).addSite(site)
+}
 
-				var fun = &FuncDescriptor{
-					Id:       pkgPath + "." + def.Name(),
-					DeclType: def.Type().String(),
-				}
+// FindFuncDecls looks for all function declarations. Functions are
+// identified by their canonical id, as in the call graph.
+func FindFuncDecls(projectPath string, packagePattern []string, envs []string, opts ...Option) (map[*FuncDescriptor]bool, error) {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a.FuncDecls(), nil
+}
 
-				funcDecls[fun] = true
-				return true
-			})
+// FuncDecls looks for all function declarations of the loaded packages.
+func (a *Analyzer) FuncDecls() map[*FuncDescriptor]bool {
+	funcDecls := make(map[*FuncDescriptor]bool)
+
+	for _, pkg := range a.Packages {
+		w := newFuncWalker(pkg)
+		w.enter = func(node ast.Node, fn, parent string) {
+			if decl, ok := node.(*ast.FuncDecl); ok {
+				funcDecls[&FuncDescriptor{
+					Id:       fn,
+					DeclType: pkg.TypesInfo.Defs[decl.Name].Type().String(),
+				}] = true
+			}
+		}
+		for _, file := range pkg.Syntax {
+			w.walkFile(file, func(ast.Node, []ast.Node, string) {})
 		}
 	}
 	return funcDecls
 }
 
 // FindInterfaces looks for all interfaces.
-func FindInterfaces(projectPath string, packagePattern []string, envs []string) map[string]bool {
+func FindInterfaces(projectPath string, packagePattern []string, envs []string, opts ...Option) (map[string]bool, error) {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a.Interfaces(), nil
+}
+
+// Interfaces looks for all interfaces of the loaded packages.
+func (a *Analyzer) Interfaces() map[string]bool {
+	if a.interfaces != nil {
+		return a.interfaces
+	}
+
 	var (
-		fset          = token.NewFileSet()
-		pkgs, _       = getPkgs(projectPath, packagePattern, fset, envs)
+		pkgs          = a.Packages
 		interaceTable = make(map[string]bool)
 	)
 
@@ -464,25 +631,6 @@ func FindInterfaces(projectPath string, packagePattern []string, envs []string)
 			})
 		}
 	}
+	a.interfaces = interaceTable
 	return interaceTable
 }
-
-// InferRootFunctionsFromGraph tries to infer entry points from passed call graph.
-func InferRootFunctionsFromGraph(callgraph map[*FuncDescriptor][]*FuncDescriptor) []*FuncDescriptor {
-	var allFunctions = make(map[*FuncDescriptor]bool)
-	var rootFunctions []*FuncDescriptor
-
-	for k, v := range callgraph {
-		allFunctions[k] = true
-		for _, childFun := range v {
-			allFunctions[childFun] = true
-		}
-	}
-	for k := range allFunctions {
-		_, exists := callgraph[k]
-		if !exists {
-			rootFunctions = append(rootFunctions, k)
-		}
-	}
-	return rootFunctions
-}
@@ -0,0 +1,104 @@
+package goretriever
+
+import "testing"
+
+func TestBuildCallGraphBrokenSyntax(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"p/p.go": `package p
+
+func f() { g() }
+
+func g() {}
+
+func Unclosed( {
+`,
+	})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := graph.Node("example.com/m/p.f"); f == nil || len(f.Out) != 1 {
+		t.Errorf("f is %+v, want a node calling g", f)
+	}
+}
+
+func TestBuildCallGraphAttribution(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"p/a.go": `package p
+
+var table = build()
+
+func init() { setup() }
+
+func run() {
+	go func() {
+		work()
+		defer func() { done() }()
+	}()
+	f := func() { done() }
+	f()
+}
+`,
+		"p/b.go": `package p
+
+var handler = func() { work() }
+
+func init() { work() }
+
+func build() int  { return 0 }
+func setup()      {}
+func work()       {}
+func done()       {}
+`,
+	})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := func(caller string) map[string]bool {
+		n := graph.Node(caller)
+		if n == nil {
+			t.Fatalf("no node for %s", caller)
+		}
+		callees := make(map[string]bool)
+		for _, c := range n.Callees() {
+			callees[c.ID] = true
+		}
+		return callees
+	}
+	const p = "example.com/m/p."
+
+	for caller, want := range map[string][]string{
+		p + "init":    {p + "build", p + "init#1", p + "init#2", p + "init$1"},
+		p + "init#1":  {p + "setup"},
+		p + "init#2":  {p + "work"},
+		p + "init$1":  {p + "work"},
+		p + "run":     {p + "run$1", p + "run$2"},
+		p + "run$1":   {p + "work", p + "run$1$1"},
+		p + "run$1$1": {p + "done"},
+		p + "run$2":   {p + "done"},
+	} {
+		got := calls(caller)
+		if len(got) != len(want) {
+			t.Errorf("%s calls %v, want %v", caller, got, want)
+			continue
+		}
+		for _, id := range want {
+			if !got[id] {
+				t.Errorf("%s calls %v, want %v", caller, got, want)
+				break
+			}
+		}
+	}
+
+	closure := graph.Node(p + "run$1$1")
+	if closure.Parent == nil || closure.Parent.ID != p+"run$1" || closure.Name != "run$1$1" {
+		t.Errorf("closure run$1$1 has parent %v and name %q", closure.Parent, closure.Name)
+	}
+	if init := graph.Node(p + "init"); !init.Synthetic {
+		t.Error("the package initializer is not synthetic")
+	}
+}
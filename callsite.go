@@ -0,0 +1,206 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// CallKind tells how a call is written.
+type CallKind int
+
+const (
+	// CallDirect calls a function by its name.
+	CallDirect CallKind = iota
+	// CallMethod calls a method of a concrete type.
+	CallMethod
+	// CallInterface calls a method through an interface.
+	CallInterface
+	// CallFuncValue calls a function value, eg. a closure or a parameter.
+	CallFuncValue
+	// CallGo starts a goroutine.
+	CallGo
+	// CallDefer defers a call.
+	CallDefer
+)
+
+func (k CallKind) String() string {
+	switch k {
+	case CallDirect:
+		return "direct"
+	case CallMethod:
+		return "method"
+	case CallInterface:
+		return "interface"
+	case CallFuncValue:
+		return "funcvalue"
+	case CallGo:
+		return "go"
+	case CallDefer:
+		return "defer"
+	}
+	return fmt.Sprintf("CallKind(%d)", int(k))
+}
+
+// CallSite is a place where the caller of an edge calls the callee.
+type CallSite struct {
+	Pos  token.Position
+	Kind CallKind
+	// Args is the source text of the arguments.
+	Args []string
+	// InLoop is set for calls in the body of a for or range loop.
+	InLoop bool
+	// InErrBranch is set for calls in the branch of an if statement
+	// taken when an error is not nil.
+	InErrBranch bool
+}
+
+// addSite records site on e, once per position.
+func (e *CallEdge) addSite(site *CallSite) {
+	if site == nil {
+		return
+	}
+	for _, s := range e.Sites {
+		if s.Pos == site.Pos {
+			return
+		}
+	}
+	e.Sites = append(e.Sites, site)
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// errCheck returns the operator of cond when it compares an error with nil.
+func errCheck(info *types.Info, cond ast.Expr) (token.Token, bool) {
+	bin, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.NEQ && bin.Op != token.EQL) {
+		return 0, false
+	}
+
+	x, y := info.Types[bin.X], info.Types[bin.Y]
+	if x.IsNil() {
+		x, y = y, x
+	}
+	if !y.IsNil() || x.Type == nil || !types.Implements(x.Type, errorType) {
+		return 0, false
+	}
+	return bin.Op, true
+}
+
+func contains(node ast.Node, pos token.Pos) bool {
+	return node != nil && node.Pos() <= pos && pos < node.End()
+}
+
+// newCallSite describes call, whose ancestors up to the enclosing
+// function are listed in stack, the closest last.
+func newCallSite(stack []ast.Node, call *ast.CallExpr, info *types.Info, fset *token.FileSet, content []byte) *CallSite {
+	site := &CallSite{Pos: fset.Position(call.Lparen)}
+
+	switch fn := typeutil.Callee(info, call).(type) {
+	case *types.Func:
+		switch {
+		case isInterfaceRecv(fn):
+			site.Kind = CallInterface
+		case fn.Type().(*types.Signature).Recv() != nil:
+			site.Kind = CallMethod
+		default:
+			site.Kind = CallDirect
+		}
+	case *types.Builtin, *types.TypeName:
+		site.Kind = CallDirect
+	default:
+		site.Kind = CallFuncValue
+	}
+
+	if len(stack) > 0 {
+		switch parent := stack[len(stack)-1].(type) {
+		case *ast.GoStmt:
+			if parent.Call == call {
+				site.Kind = CallGo
+			}
+		case *ast.DeferStmt:
+			if parent.Call == call {
+				site.Kind = CallDefer
+			}
+		}
+	}
+
+	for _, arg := range call.Args {
+		beg, end := fset.Position(arg.Pos()).Offset, fset.Position(arg.End()).Offset
+		if content != nil && 0 <= beg && beg <= end && end <= len(content) {
+			site.Args = append(site.Args, string(content[beg:end]))
+		} else {
+			site.Args = append(site.Args, types.ExprString(arg))
+		}
+	}
+
+ancestors:
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch s := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			break ancestors
+		case *ast.ForStmt:
+			site.InLoop = site.InLoop || contains(s.Body, call.Pos())
+		case *ast.RangeStmt:
+			site.InLoop = site.InLoop || contains(s.Body, call.Pos())
+		case *ast.IfStmt:
+			op, ok := errCheck(info, s.Cond)
+			if !ok {
+				continue
+			}
+			if op == token.NEQ && contains(s.Body, call.Pos()) ||
+				op == token.EQL && contains(s.Else, call.Pos()) {
+				site.InErrBranch = true
+			}
+		}
+	}
+	return site
+}
+
+// isInterfaceRecv reports whether fn is a method of an interface,
+// including generic ones.
+func isInterfaceRecv(fn *types.Func) bool {
+	recv := fn.Type().(*types.Signature).Recv()
+	return recv != nil && types.IsInterface(recv.Type())
+}
+
+// callSiteIndexer walks the syntax of a package with the ancestors of
+// the current node, collecting the call sites.
+type callSiteIndexer struct {
+	info    *types.Info
+	fset    *token.FileSet
+	content []byte
+	sites   map[token.Pos]*CallSite
+}
+
+// indexFile records the call sites of file by the position of their
+// left parenthesis, and of the go or defer keyword.
+func (x *callSiteIndexer) indexFile(file *ast.File) {
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		if call, ok := n.(*ast.CallExpr); ok {
+			site := newCallSite(stack, call, x.info, x.fset, x.content)
+			x.sites[call.Lparen] = site
+			switch parent := stack[len(stack)-1].(type) {
+			case *ast.GoStmt:
+				if parent.Call == call {
+					x.sites[parent.Go] = site
+				}
+			case *ast.DeferStmt:
+				if parent.Call == call {
+					x.sites[parent.Defer] = site
+				}
+			}
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
@@ -0,0 +1,104 @@
+package goretriever
+
+import (
+	"strings"
+	"testing"
+)
+
+const callSiteSource = `package job
+
+type Runner interface{ Run(n int) error }
+
+type Job struct{}
+
+func (Job) Run(n int) error { return nil }
+
+func step(n int, name string) error { return nil }
+
+func cleanup() {}
+
+func report(err error) {}
+
+func Work(r Runner, f func()) {
+	defer cleanup()
+	for i := 0; i < 3; i++ {
+		if err := step(i+1, "a b"); err != nil {
+			report(err)
+		}
+	}
+	go f()
+	var j Job
+	if err := j.Run(2); err == nil {
+		cleanup()
+	} else {
+		report(err)
+	}
+	r.Run(0)
+	for range []int{1} {
+		func() { cleanup() }()
+	}
+}
+`
+
+func TestCallSites(t *testing.T) {
+	dir := writeModule(t, map[string]string{"job/job.go": callSiteSource})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sites := make(map[string][]*CallSite)
+	for _, e := range graph.Edges() {
+		for _, site := range e.Sites {
+			key := strings.TrimPrefix(e.Caller.ID, "example.com/m/job.") + " -> " +
+				strings.TrimPrefix(e.Callee.ID, "example.com/m/job.")
+			sites[key] = append(sites[key], site)
+		}
+	}
+
+	tests := []struct {
+		edge        string
+		line        int
+		kind        CallKind
+		args        []string
+		inLoop      bool
+		inErrBranch bool
+	}{
+		{"Work -> cleanup", 16, CallDefer, nil, false, false},
+		{"Work -> step", 18, CallDirect, []string{"i+1", `"a b"`}, true, false},
+		{"Work -> report", 19, CallDirect, []string{"err"}, true, true},
+		{"Work -> (example.com/m/job.Job).Run", 24, CallMethod, []string{"2"}, false, false},
+		{"Work -> cleanup", 25, CallDirect, nil, false, false},
+		{"Work -> report", 27, CallDirect, []string{"err"}, false, true},
+		{"Work -> (example.com/m/job.Runner).Run", 29, CallInterface, []string{"0"}, false, false},
+		{"Work -> Work$1", 31, CallFuncValue, nil, true, false},
+		// loops around a closure are not the closure's loops
+		{"Work$1 -> cleanup", 31, CallDirect, nil, false, false},
+	}
+
+	for _, test := range tests {
+		var site *CallSite
+		for _, s := range sites[test.edge] {
+			if s.Pos.Line == test.line {
+				site = s
+			}
+		}
+		if site == nil {
+			t.Errorf("%s: no call site on line %d", test.edge, test.line)
+			continue
+		}
+		if site.Kind != test.kind {
+			t.Errorf("%s:%d: kind is %s, want %s", test.edge, test.line, site.Kind, test.kind)
+		}
+		if strings.Join(site.Args, ", ") != strings.Join(test.args, ", ") {
+			t.Errorf("%s:%d: args are %q, want %q", test.edge, test.line, site.Args, test.args)
+		}
+		if site.InLoop != test.inLoop {
+			t.Errorf("%s:%d: InLoop is %t, want %t", test.edge, test.line, site.InLoop, test.inLoop)
+		}
+		if site.InErrBranch != test.inErrBranch {
+			t.Errorf("%s:%d: InErrBranch is %t, want %t", test.edge, test.line, site.InErrBranch, test.inErrBranch)
+		}
+	}
+}
@@ -0,0 +1,535 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"math"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Spawn is a go statement.
+type Spawn struct {
+	Spawner *CallNode
+	// Target is the function run by the goroutine, nil when unknown.
+	// Dynamic calls may have several targets, each with its own Spawn.
+	Target *CallNode
+	Pos    token.Position
+	Site   *CallSite
+}
+
+// ChanOpKind is an operation on a channel.
+type ChanOpKind int
+
+const (
+	ChanSend ChanOpKind = iota
+	ChanRecv
+	ChanClose
+)
+
+func (k ChanOpKind) String() string {
+	switch k {
+	case ChanSend:
+		return "send"
+	case ChanRecv:
+		return "recv"
+	case ChanClose:
+		return "close"
+	}
+	return fmt.Sprintf("ChanOpKind(%d)", int(k))
+}
+
+// ChanOp is a send, a receive or a close made by a function.
+type ChanOp struct {
+	Kind    ChanOpKind
+	Func    *CallNode
+	Pos     token.Position
+	Channel *Channel
+	// Select is set for the cases of select statements.
+	Select bool
+}
+
+// Channel is a channel created by a make call. Channels whose creation
+// could not be found, eg. received from a dependency, have one Channel
+// per type, with no creator.
+type Channel struct {
+	// ID is the creator and the position of the make call, or the type
+	// prefixed with ? for channels of unknown origin.
+	ID      string
+	Type    string
+	Pos     token.Position
+	Creator *CallNode
+	// Size is the buffer size as a constant, or "" when not constant.
+	Size string
+	Ops  []*ChanOp
+}
+
+// Senders returns the functions sending to the channel, ordered by id.
+func (ch *Channel) Senders() []*CallNode {
+	return ch.funcs(ChanSend)
+}
+
+// Receivers returns the functions receiving from the channel, ordered
+// by id.
+func (ch *Channel) Receivers() []*CallNode {
+	return ch.funcs(ChanRecv)
+}
+
+// Closers returns the functions closing the channel, ordered by id.
+func (ch *Channel) Closers() []*CallNode {
+	return ch.funcs(ChanClose)
+}
+
+func (ch *Channel) funcs(kind ChanOpKind) []*CallNode {
+	var (
+		nodes []*CallNode
+		seen  = make(map[*CallNode]bool)
+	)
+	for _, op := range ch.Ops {
+		if op.Kind == kind && !seen[op.Func] {
+			seen[op.Func] = true
+			nodes = append(nodes, op.Func)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// ConcurrencyGraph links the functions of the loaded packages to the
+// goroutines they start and to the channels they use.
+type ConcurrencyGraph struct {
+	// Spawns are ordered by position.
+	Spawns []*Spawn
+	// Channels are ordered by id.
+	Channels []*Channel
+}
+
+// SpawnsBy returns the goroutines started by the function id.
+func (g *ConcurrencyGraph) SpawnsBy(id string) []*Spawn {
+	var spawns []*Spawn
+	for _, s := range g.Spawns {
+		if s.Spawner.ID == id {
+			spawns = append(spawns, s)
+		}
+	}
+	return spawns
+}
+
+// SpawnsOf returns the go statements running the function id.
+func (g *ConcurrencyGraph) SpawnsOf(id string) []*Spawn {
+	var spawns []*Spawn
+	for _, s := range g.Spawns {
+		if s.Target != nil && s.Target.ID == id {
+			spawns = append(spawns, s)
+		}
+	}
+	return spawns
+}
+
+// OpsOf returns the channel operations made by the function id.
+func (g *ConcurrencyGraph) OpsOf(id string) []*ChanOp {
+	var ops []*ChanOp
+	for _, ch := range g.Channels {
+		for _, op := range ch.Ops {
+			if op.Func.ID == id {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// Channel returns the channel with the given id, or nil.
+func (g *ConcurrencyGraph) Channel(id string) *Channel {
+	for _, ch := range g.Channels {
+		if ch.ID == id {
+			return ch
+		}
+	}
+	return nil
+}
+
+// fieldKey identifies a struct field of any value of a struct type.
+type fieldKey struct {
+	typ   string
+	index int
+}
+
+// concurrencyBuilder follows the SSA values of channels back to the
+// make calls creating them. Values flow through phis, conversions,
+// parameters, closure bindings, results, variables and struct fields,
+// the latter being identified by type and not by value.
+type concurrencyBuilder struct {
+	c        *ssaConverter
+	cg       *callgraph.Graph
+	stores   map[any][]ssa.Value
+	closures map[*ssa.Function][]*ssa.MakeClosure
+	returns  map[*ssa.Function][]*ssa.Return
+	channels map[*ssa.MakeChan]*Channel
+	unknown  map[string]*Channel
+	memo     map[ssa.Value][]*Channel
+	// visiting maps the values being computed to their depth, low is
+	// the lowest depth a cycle was cut at by the current computation
+	visiting map[ssa.Value]int
+	low      int
+}
+
+// Concurrency builds the goroutine and channel graph of the loaded
+// packages from their SSA form. Dynamic calls are resolved with VTA.
+func (a *Analyzer) Concurrency() *ConcurrencyGraph {
+	c := a.newSSAConverter()
+	b := &concurrencyBuilder{
+		c:        c,
+		cg:       vta.CallGraph(ssautil.AllFunctions(c.prog), cha.CallGraph(c.prog)),
+		stores:   make(map[any][]ssa.Value),
+		closures: make(map[*ssa.Function][]*ssa.MakeClosure),
+		returns:  make(map[*ssa.Function][]*ssa.Return),
+		channels: make(map[*ssa.MakeChan]*Channel),
+		unknown:  make(map[string]*Channel),
+		memo:     make(map[ssa.Value][]*Channel),
+		visiting: make(map[ssa.Value]int),
+		low:      math.MaxInt,
+	}
+
+	// index the stores, closures and returns first, values may flow
+	// from any function
+	for _, fn := range c.funcs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					for _, key := range b.addrKeys(instr.Addr, make(map[ssa.Value]bool)) {
+						b.stores[key] = append(b.stores[key], instr.Val)
+					}
+				case *ssa.MakeClosure:
+					if fn, ok := instr.Fn.(*ssa.Function); ok {
+						b.closures[fn] = append(b.closures[fn], instr)
+					}
+				case *ssa.Return:
+					b.returns[fn] = append(b.returns[fn], instr)
+				}
+			}
+		}
+	}
+
+	g := &ConcurrencyGraph{}
+	for _, fn := range c.funcs {
+		if fn.Synthetic != "" && fn.Synthetic != "package initializer" {
+			continue
+		}
+		node := c.node(fn)
+
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.MakeChan:
+					b.channel(instr)
+
+				case *ssa.Go:
+					targets := []*CallNode{nil}
+					if callees := b.callees(instr); len(callees) > 0 {
+						targets = targets[:0]
+						for _, callee := range callees {
+							targets = append(targets, c.node(callee))
+						}
+					}
+					for _, target := range targets {
+						g.Spawns = append(g.Spawns, &Spawn{
+							Spawner: node,
+							Target:  target,
+							Pos:     a.Fset.Position(instr.Pos()),
+							Site:    c.sites[instr.Pos()],
+						})
+					}
+
+				case *ssa.Send:
+					b.addOp(ChanSend, node, instr.Pos(), instr.Chan, false)
+
+				case *ssa.UnOp:
+					if instr.Op == token.ARROW {
+						b.addOp(ChanRecv, node, instr.Pos(), instr.X, false)
+					}
+
+				case *ssa.Select:
+					for _, state := range instr.States {
+						kind := ChanRecv
+						if state.Dir == types.SendOnly {
+							kind = ChanSend
+						}
+						b.addOp(kind, node, state.Pos, state.Chan, true)
+					}
+
+				case *ssa.Call, *ssa.Defer:
+					// close(ch) and defer close(ch)
+					common := instr.(ssa.CallInstruction).Common()
+					if builtin, ok := common.Value.(*ssa.Builtin); ok &&
+						builtin.Name() == "close" && len(common.Args) == 1 {
+						b.addOp(ChanClose, node, instr.Pos(), common.Args[0], false)
+					}
+				}
+			}
+		}
+	}
+
+	for _, ch := range b.channels {
+		g.Channels = append(g.Channels, ch)
+	}
+	for _, ch := range b.unknown {
+		g.Channels = append(g.Channels, ch)
+	}
+	sort.Slice(g.Channels, func(i, j int) bool { return g.Channels[i].ID < g.Channels[j].ID })
+	for _, ch := range g.Channels {
+		sort.SliceStable(ch.Ops, func(i, j int) bool { return positionLess(ch.Ops[i].Pos, ch.Ops[j].Pos) })
+	}
+	sort.SliceStable(g.Spawns, func(i, j int) bool { return positionLess(g.Spawns[i].Pos, g.Spawns[j].Pos) })
+	return g
+}
+
+func positionLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
+
+// channel returns the channel created by mc.
+func (b *concurrencyBuilder) channel(mc *ssa.MakeChan) *Channel {
+	if ch, ok := b.channels[mc]; ok {
+		return ch
+	}
+
+	creator := b.c.node(mc.Parent())
+	pos := b.c.a.Fset.Position(mc.Pos())
+	ch := &Channel{
+		ID:      fmt.Sprintf("%s:%d:%d", creator.ID, pos.Line, pos.Column),
+		Type:    mc.Type().String(),
+		Pos:     pos,
+		Creator: creator,
+	}
+	if size, ok := mc.Size.(*ssa.Const); ok && size.Value != nil {
+		ch.Size = size.Value.String()
+	}
+	b.channels[mc] = ch
+	return ch
+}
+
+func (b *concurrencyBuilder) addOp(kind ChanOpKind, fn *CallNode, pos token.Pos, v ssa.Value, sel bool) {
+	channels := b.origins(v)
+	if len(channels) == 0 {
+		typ := v.Type().String()
+		ch, ok := b.unknown[typ]
+		if !ok {
+			ch = &Channel{ID: "?" + typ, Type: typ}
+			b.unknown[typ] = ch
+		}
+		channels = []*Channel{ch}
+	}
+
+	position := b.c.a.Fset.Position(pos)
+	if !position.IsValid() {
+		position = fn.Pos
+	}
+	for _, ch := range channels {
+		ch.Ops = append(ch.Ops, &ChanOp{
+			Kind:    kind,
+			Func:    fn,
+			Pos:     position,
+			Channel: ch,
+			Select:  sel,
+		})
+	}
+}
+
+// callees returns the functions called by instr, ordered by name.
+func (b *concurrencyBuilder) callees(instr ssa.CallInstruction) []*ssa.Function {
+	if callee := instr.Common().StaticCallee(); callee != nil {
+		return []*ssa.Function{callee}
+	}
+
+	var callees []*ssa.Function
+	if n := b.cg.Nodes[instr.Parent()]; n != nil {
+		for _, e := range n.Out {
+			if e.Site == instr {
+				callees = append(callees, e.Callee.Func)
+			}
+		}
+	}
+	sort.Slice(callees, func(i, j int) bool { return callees[i].String() < callees[j].String() })
+	return callees
+}
+
+// addrKeys returns the keys of the stores to addr: the variables and
+// globals it may point to, or the struct field it is the address of.
+func (b *concurrencyBuilder) addrKeys(addr ssa.Value, seen map[ssa.Value]bool) []any {
+	if seen[addr] {
+		return nil
+	}
+	seen[addr] = true
+
+	switch addr := addr.(type) {
+	case *ssa.Alloc, *ssa.Global:
+		return []any{addr}
+	case *ssa.FieldAddr:
+		return []any{fieldKey{typ: addr.X.Type().Underlying().(*types.Pointer).Elem().String(), index: addr.Field}}
+	case *ssa.FreeVar:
+		var keys []any
+		for _, binding := range b.bindings(addr) {
+			keys = append(keys, b.addrKeys(binding, seen)...)
+		}
+		return keys
+	case *ssa.Parameter:
+		var keys []any
+		for _, arg := range b.args(addr) {
+			keys = append(keys, b.addrKeys(arg, seen)...)
+		}
+		return keys
+	case *ssa.Phi:
+		var keys []any
+		for _, edge := range addr.Edges {
+			keys = append(keys, b.addrKeys(edge, seen)...)
+		}
+		return keys
+	}
+	return nil
+}
+
+// bindings returns the values bound to fv by the closures of its function.
+func (b *concurrencyBuilder) bindings(fv *ssa.FreeVar) []ssa.Value {
+	fn := fv.Parent()
+	for i, v := range fn.FreeVars {
+		if v != fv {
+			continue
+		}
+		var values []ssa.Value
+		for _, mc := range b.closures[fn] {
+			values = append(values, mc.Bindings[i])
+		}
+		return values
+	}
+	return nil
+}
+
+// args returns the arguments passed for p by the callers of its function.
+func (b *concurrencyBuilder) args(p *ssa.Parameter) []ssa.Value {
+	fn := p.Parent()
+	index := -1
+	for i, param := range fn.Params {
+		if param == p {
+			index = i
+		}
+	}
+	n := b.cg.Nodes[fn]
+	if index < 0 || n == nil {
+		return nil
+	}
+
+	var values []ssa.Value
+	for _, e := range n.In {
+		if e.Site == nil {
+			continue
+		}
+		common := e.Site.Common()
+		args := common.Args
+		if common.IsInvoke() {
+			args = append([]ssa.Value{common.Value}, args...)
+		}
+		if index < len(args) {
+			values = append(values, args[index])
+		}
+	}
+	return values
+}
+
+// results returns the values returned at position index by the callees
+// of call.
+func (b *concurrencyBuilder) results(call *ssa.Call, index int) []ssa.Value {
+	var values []ssa.Value
+	for _, callee := range b.callees(call) {
+		for _, ret := range b.returns[callee] {
+			if index < len(ret.Results) {
+				values = append(values, ret.Results[index])
+			}
+		}
+	}
+	return values
+}
+
+// origins returns the channels v may hold. Values flowing in cycles
+// are cut at the first value of the cycle, the others are only
+// memoized once it is complete.
+func (b *concurrencyBuilder) origins(v ssa.Value) []*Channel {
+	if channels, ok := b.memo[v]; ok {
+		return channels
+	}
+	if depth, ok := b.visiting[v]; ok {
+		b.low = min(b.low, depth)
+		return nil
+	}
+	depth := len(b.visiting)
+	b.visiting[v] = depth
+	low := b.low
+	b.low = math.MaxInt
+	defer func() {
+		delete(b.visiting, v)
+		b.low = min(low, b.low)
+	}()
+
+	var (
+		channels []*Channel
+		seen     = make(map[*Channel]bool)
+	)
+	add := func(values ...ssa.Value) {
+		for _, value := range values {
+			for _, ch := range b.origins(value) {
+				if !seen[ch] {
+					seen[ch] = true
+					channels = append(channels, ch)
+				}
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case *ssa.MakeChan:
+		channels = []*Channel{b.channel(v)}
+	case *ssa.Phi:
+		add(v.Edges...)
+	case *ssa.ChangeType:
+		add(v.X)
+	case *ssa.MakeInterface:
+		add(v.X)
+	case *ssa.TypeAssert:
+		add(v.X)
+	case *ssa.Parameter:
+		add(b.args(v)...)
+	case *ssa.FreeVar:
+		add(b.bindings(v)...)
+	case *ssa.Call:
+		add(b.results(v, 0)...)
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok {
+			add(b.results(call, v.Index)...)
+		}
+	case *ssa.UnOp:
+		if v.Op == token.MUL {
+			for _, key := range b.addrKeys(v.X, make(map[ssa.Value]bool)) {
+				add(b.stores[key]...)
+			}
+		}
+	case *ssa.Field:
+		add(b.stores[fieldKey{typ: v.X.Type().String(), index: v.Field}]...)
+	}
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].ID < channels[j].ID })
+	// cycles cut above v leave it incomplete
+	if b.low >= depth {
+		b.memo[v] = channels
+	}
+	return channels
+}
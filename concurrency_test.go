@@ -0,0 +1,129 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const pipelineSource = `package pipe
+
+type Stage struct{ out chan int }
+
+func newStage() *Stage { return &Stage{out: make(chan int, 4)} }
+
+func produce(out chan<- int, n int) {
+	for i := 0; i < n; i++ {
+		out <- i
+	}
+	close(out)
+}
+
+func consume(in <-chan int, done chan struct{}) {
+	for range in {
+	}
+	done <- struct{}{}
+}
+
+func Run(n int, quit chan bool) {
+	s := newStage()
+	done := make(chan struct{})
+	go produce(s.out, n)
+	go consume(s.out, done)
+	go func() {
+		select {
+		case <-done:
+		case <-quit:
+		}
+	}()
+	<-done
+}
+`
+
+func TestConcurrency(t *testing.T) {
+	dir := writeModule(t, map[string]string{"pipe/pipe.go": pipelineSource})
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := a.Concurrency()
+
+	trim := func(id string) string { return strings.TrimPrefix(id, "example.com/m/pipe.") }
+
+	var spawns []string
+	for _, s := range g.SpawnsBy("example.com/m/pipe.Run") {
+		if s.Site == nil || s.Site.Kind != CallGo {
+			t.Errorf("spawn of %s has site %+v", s.Target.ID, s.Site)
+		}
+		spawns = append(spawns, fmt.Sprintf("%d:%s", s.Pos.Line, trim(s.Target.ID)))
+	}
+	if got, want := strings.Join(spawns, " "), "23:produce 24:consume 25:Run$1"; got != want {
+		t.Errorf("spawns are %q, want %q", got, want)
+	}
+	if spawns := g.SpawnsOf("example.com/m/pipe.consume"); len(spawns) != 1 || spawns[0].Spawner.ID != "example.com/m/pipe.Run" {
+		t.Errorf("spawns of consume are %v", spawns)
+	}
+
+	var channels []string
+	for _, ch := range g.Channels {
+		var ops []string
+		for _, op := range ch.Ops {
+			op := fmt.Sprintf("%s:%s:%d", op.Kind, trim(op.Func.ID), op.Pos.Line)
+			ops = append(ops, op)
+		}
+		channels = append(channels, fmt.Sprintf("%s %s(%s) %s", trim(ch.ID), ch.Type, ch.Size, strings.Join(ops, " ")))
+	}
+	want := []string{
+		// quit comes from the callers of Run, which are not analyzed
+		"?chan bool chan bool() recv:Run$1:28",
+		"Run:22:14 chan struct{}(0) send:consume:17 recv:Run$1:27 recv:Run:31",
+		// out flows through a struct field and a parameter
+		"newStage:5:49 chan int(4) send:produce:9 close:produce:11 recv:consume:15",
+	}
+	if strings.Join(channels, "\n") != strings.Join(want, "\n") {
+		t.Errorf("channels are\n%s\nwant\n%s", strings.Join(channels, "\n"), strings.Join(want, "\n"))
+	}
+
+	done := g.Channel("example.com/m/pipe.Run:22:14")
+	if done == nil || done.Creator.ID != "example.com/m/pipe.Run" {
+		t.Fatalf("done channel is %+v", done)
+	}
+	if senders := done.Senders(); len(senders) != 1 || trim(senders[0].ID) != "consume" {
+		t.Errorf("senders of done are %v", senders)
+	}
+	if receivers := done.Receivers(); len(receivers) != 2 || trim(receivers[0].ID) != "Run" || trim(receivers[1].ID) != "Run$1" {
+		t.Errorf("receivers of done are %v", receivers)
+	}
+	for _, op := range g.OpsOf("example.com/m/pipe.Run$1") {
+		if !op.Select {
+			t.Errorf("receive of Run$1 at %s is not a select case", op.Pos)
+		}
+	}
+	if closers := g.Channel("example.com/m/pipe.newStage:5:49").Closers(); len(closers) != 1 || trim(closers[0].ID) != "produce" {
+		t.Errorf("closers of out are %v", closers)
+	}
+}
+
+func TestExportConcurrencyGraph(t *testing.T) {
+	dir := writeModule(t, map[string]string{"pipe/pipe.go": pipelineSource})
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &strings.Builder{}
+	if err := ExportConcurrencyGraph(b, a.Concurrency(), GraphDOT); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`"example.com/m/pipe.newStage:5:49" [label="chan int (4) @pipe.go:5:49", shape=ellipse];`,
+		`"example.com/m/pipe.Run" -> "example.com/m/pipe.produce" [label="go", style=bold];`,
+		`"example.com/m/pipe.produce" -> "example.com/m/pipe.newStage:5:49" [label="close", style=dotted];`,
+		`"example.com/m/pipe.newStage:5:49" -> "example.com/m/pipe.consume" [label="recv"];`,
+		`"?chan bool" [label="chan bool", shape=ellipse, style=dashed];`,
+	} {
+		if !strings.Contains(b.String(), "\t"+line+"\n") {
+			t.Errorf("no line %s in\n%s", line, b)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package goretriever
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type exportSpawn struct {
+	Spawner string `json:"spawner"`
+	Target  string `json:"target,omitempty"`
+	Pos     string `json:"pos"`
+}
+
+type exportChanOp struct {
+	Kind   string `json:"kind"`
+	Func   string `json:"func"`
+	Pos    string `json:"pos"`
+	Select bool   `json:"select,omitempty"`
+}
+
+type exportChannel struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Pos     string          `json:"pos,omitempty"`
+	Creator string          `json:"creator,omitempty"`
+	Size    string          `json:"size,omitempty"`
+	Ops     []*exportChanOp `json:"ops"`
+}
+
+type exportConcurrency struct {
+	Functions []*exportNode    `json:"functions"`
+	Spawns    []*exportSpawn   `json:"spawns"`
+	Channels  []*exportChannel `json:"channels"`
+}
+
+// concurrencyEdge is an arrow of the rendered graph, between functions
+// or between a function and a channel.
+type concurrencyEdge struct {
+	source, target string
+	label          string
+	style          string
+}
+
+func newExportConcurrency(g *ConcurrencyGraph) *exportConcurrency {
+	var (
+		x     = &exportConcurrency{}
+		funcs = make(map[string]bool)
+	)
+	addFunc := func(n *CallNode) string {
+		if n == nil {
+			return ""
+		}
+		if !funcs[n.ID] {
+			funcs[n.ID] = true
+			x.Functions = append(x.Functions, &exportNode{ID: n.ID, Label: nodeLabel(n), Package: n.Pkg})
+		}
+		return n.ID
+	}
+
+	for _, s := range g.Spawns {
+		x.Spawns = append(x.Spawns, &exportSpawn{
+			Spawner: addFunc(s.Spawner),
+			Target:  addFunc(s.Target),
+			Pos:     s.Pos.String(),
+		})
+	}
+	for _, ch := range g.Channels {
+		c := &exportChannel{ID: ch.ID, Type: ch.Type, Creator: addFunc(ch.Creator), Size: ch.Size}
+		if ch.Pos.IsValid() {
+			c.Pos = ch.Pos.String()
+		}
+		for _, op := range ch.Ops {
+			c.Ops = append(c.Ops, &exportChanOp{
+				Kind:   op.Kind.String(),
+				Func:   addFunc(op.Func),
+				Pos:    op.Pos.String(),
+				Select: op.Select,
+			})
+		}
+		x.Channels = append(x.Channels, c)
+	}
+
+	sort.Slice(x.Functions, func(i, j int) bool { return x.Functions[i].ID < x.Functions[j].ID })
+	return x
+}
+
+// edges returns the arrows of the graph without duplicates: spawns go
+// from the spawner to the target, sends and closes from the function to
+// the channel, receives from the channel to the function.
+func (x *exportConcurrency) edges() []*concurrencyEdge {
+	var (
+		edges []*concurrencyEdge
+		seen  = make(map[concurrencyEdge]bool)
+	)
+	add := func(e concurrencyEdge) {
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, &e)
+		}
+	}
+
+	for _, s := range x.Spawns {
+		if s.Target == "" {
+			continue
+		}
+		add(concurrencyEdge{source: s.Spawner, target: s.Target, label: "go", style: "bold"})
+	}
+	for _, ch := range x.Channels {
+		if ch.Creator != "" {
+			add(concurrencyEdge{source: ch.Creator, target: ch.ID, label: "make", style: "dashed"})
+		}
+		for _, op := range ch.Ops {
+			switch op.Kind {
+			case "recv":
+				add(concurrencyEdge{source: ch.ID, target: op.Func, label: op.Kind})
+			case "close":
+				add(concurrencyEdge{source: op.Func, target: ch.ID, label: op.Kind, style: "dotted"})
+			default:
+				add(concurrencyEdge{source: op.Func, target: ch.ID, label: op.Kind})
+			}
+		}
+	}
+	return edges
+}
+
+// channelLabel names a channel by its type and the line of its make call.
+func channelLabel(ch *exportChannel) string {
+	if ch.Pos == "" {
+		return ch.Type
+	}
+	label := ch.Type
+	if ch.Size != "" && ch.Size != "0" {
+		label += " (" + ch.Size + ")"
+	}
+	return label + " @" + ch.Pos[strings.LastIndex(ch.Pos, "/")+1:]
+}
+
+func (x *exportConcurrency) writeDOT(b *strings.Builder) {
+	b.WriteString("digraph concurrency {\n\tnode [shape=box];\n")
+	for _, n := range x.Functions {
+		fmt.Fprintf(b, "\t%s [label=%s];\n", dotQuote(n.ID), dotQuote(n.Label))
+	}
+	for _, ch := range x.Channels {
+		fmt.Fprintf(b, "\t%s [label=%s, shape=ellipse", dotQuote(ch.ID), dotQuote(channelLabel(ch)))
+		if ch.Creator == "" {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+	for _, e := range x.edges() {
+		fmt.Fprintf(b, "\t%s -> %s [label=%s", dotQuote(e.source), dotQuote(e.target), dotQuote(e.label))
+		if e.style != "" {
+			fmt.Fprintf(b, ", style=%s", e.style)
+		}
+		b.WriteString("];\n")
+	}
+	b.WriteString("}\n")
+}
+
+func (x *exportConcurrency) writeMermaid(b *strings.Builder) {
+	b.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string, len(x.Functions)+len(x.Channels))
+	for i, n := range x.Functions {
+		ids[n.ID] = fmt.Sprintf("f%d", i)
+		fmt.Fprintf(b, "\t%s[%s]\n", ids[n.ID], mermaidQuote(n.Label))
+	}
+	for i, ch := range x.Channels {
+		ids[ch.ID] = fmt.Sprintf("c%d", i)
+		fmt.Fprintf(b, "\t%s([%s])\n", ids[ch.ID], mermaidQuote(channelLabel(ch)))
+	}
+
+	for _, e := range x.edges() {
+		arrow := "-->"
+		switch e.style {
+		case "bold":
+			arrow = "==>"
+		case "dashed", "dotted":
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "\t%s %s|%s| %s\n", ids[e.source], arrow, e.label, ids[e.target])
+	}
+}
+
+// ExportConcurrencyGraph writes g in the DOT, Mermaid or JSON format.
+// Functions are boxes and channels ellipses.
+func ExportConcurrencyGraph(w io.Writer, g *ConcurrencyGraph, format GraphFormat) error {
+	x := newExportConcurrency(g)
+	b := &strings.Builder{}
+
+	switch format {
+	case GraphDOT:
+		x.writeDOT(b)
+	case GraphMermaid:
+		x.writeMermaid(b)
+	case GraphJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(x)
+	default:
+		return fmt.Errorf("unsupported concurrency graph format %d", format)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
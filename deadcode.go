@@ -0,0 +1,270 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// DeadCodeOptions configures the entry points of the dead code analysis.
+type DeadCodeOptions struct {
+	// ExportedAPI makes the exported functions and methods entry points,
+	// for libraries.
+	ExportedAPI bool
+}
+
+// DeadCodeReport lists the functions of the loaded packages which can
+// not be reached from any entry point.
+type DeadCodeReport struct {
+	// Roots are the entry points the analysis started from.
+	Roots []*CallNode
+	// Dead are the unreachable functions and methods, ordered by position.
+	Dead []*CallNode
+}
+
+func (r *DeadCodeReport) String() string {
+	b := &strings.Builder{}
+	for _, n := range r.Dead {
+		fmt.Fprintf(b, "%s: %s is unreachable\n", n.Pos, n.ID)
+	}
+	return b.String()
+}
+
+// DeadCode reports the functions and methods of the loaded packages which
+// are not reachable in callGraph, or in the graph built by BuildCallGraph
+// when nil. The entry points are the ones of InferRoots, the tests being
+// found when loaded WithTests, and the functions referenced as values.
+// The exported API is only an entry point with opts.ExportedAPI.
+//
+// Methods implementing an interface of a dependency, eg. String, are
+// entry points too, since they may be called by code which is not
+// analyzed. Closures are reported through their enclosing function.
+func (a *Analyzer) DeadCode(callGraph *CallGraph, opts *DeadCodeOptions) *DeadCodeReport {
+	if callGraph == nil {
+		callGraph = a.BuildCallGraph()
+	}
+	if opts == nil {
+		opts = &DeadCodeOptions{}
+	}
+
+	var (
+		report  = &DeadCodeReport{}
+		isRoot  = make(map[*CallNode]bool)
+		values  = a.valueFuncs()
+		methods = a.externalInterfaceMethods()
+	)
+	loaded := make(map[string]bool)
+	for _, pkg := range a.Packages {
+		loaded[pkg.PkgPath] = true
+	}
+	addRoot := func(n *CallNode) {
+		if loaded[n.Pkg] && !isRoot[n] {
+			isRoot[n] = true
+			report.Roots = append(report.Roots, n)
+		}
+	}
+
+	for _, r := range a.InferRoots(callGraph) {
+		if len(r.Reasons) == 1 && r.Reasons[0] == RootExported && !opts.ExportedAPI {
+			continue
+		}
+		addRoot(r.Node)
+	}
+	for _, n := range callGraph.Nodes() {
+		if n.Func != nil && (values[n.Func] || methods[n.Func]) {
+			addRoot(n)
+		}
+	}
+
+	ids := make([]string, len(report.Roots))
+	for i, n := range report.Roots {
+		ids[i] = n.ID
+	}
+	reachable := make(map[*CallNode]bool)
+	for _, r := range callGraph.Reachable(ids, nil) {
+		reachable[r.Node] = true
+	}
+
+	for _, n := range callGraph.Nodes() {
+		if n.Pos.IsValid() && !n.Synthetic && n.Parent == nil && !reachable[n] {
+			report.Dead = append(report.Dead, n)
+		}
+	}
+	sort.SliceStable(report.Dead, func(i, j int) bool {
+		if report.Dead[i].Pos.Filename != report.Dead[j].Pos.Filename {
+			return report.Dead[i].Pos.Filename < report.Dead[j].Pos.Filename
+		}
+		return report.Dead[i].Pos.Offset < report.Dead[j].Pos.Offset
+	})
+	return report
+}
+
+// isMainFunc reports whether n is the main function of a main package.
+func isMainFunc(n *CallNode) bool {
+	return n.Func != nil && n.Func.Pkg() != nil && n.Func.Pkg().Name() == "main" &&
+		n.Recv == "" && n.Name == "main"
+}
+
+// isInitFunc reports whether n is a package initializer or an init
+// function.
+func isInitFunc(n *CallNode) bool {
+	return n.Recv == "" && n.Parent == nil &&
+		(n.Synthetic && n.Name == "init" || strings.HasPrefix(n.ID, n.Pkg+".init#"))
+}
+
+// testReason returns the kind of test function n is, or 0 when n is not
+// a test function.
+func testReason(n *CallNode) RootReason {
+	if n.Func == nil || n.Recv != "" || !strings.HasSuffix(n.Pos.Filename, "_test.go") {
+		return 0
+	}
+
+	sig := n.Func.Type().(*types.Signature)
+	param := func(typ string) bool {
+		return sig.Params().Len() == 1 && sig.Results().Len() == 0 &&
+			sig.Params().At(0).Type().String() == typ
+	}
+	switch {
+	case testName(n.Name, "Test") && param("*testing.T"):
+		return RootTest
+	case testName(n.Name, "Benchmark") && param("*testing.B"):
+		return RootBenchmark
+	case testName(n.Name, "Fuzz") && param("*testing.F"):
+		return RootFuzz
+	case testName(n.Name, "Example") && sig.Params().Len() == 0 && sig.Results().Len() == 0:
+		return RootExample
+	}
+	return 0
+}
+
+// testName reports whether name is prefix followed by nothing or by
+// a name which does not start with a lower case letter.
+func testName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	rest := name[len(prefix):]
+	return rest == "" || !(rest[0] >= 'a' && rest[0] <= 'z')
+}
+
+// isExportedAPI reports whether fn is an exported function or an
+// exported method of an exported type.
+func isExportedAPI(fn *types.Func) bool {
+	if !fn.Exported() {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	return recv == nil || token.IsExported(recvTypeName(recv.Type()))
+}
+
+// valueFuncs returns the functions and methods of the loaded packages
+// which are used other than called, eg. passed as callbacks.
+func (a *Analyzer) valueFuncs() map[*types.Func]bool {
+	values := make(map[*types.Func]bool)
+
+	for _, pkg := range a.Packages {
+		called := make(map[*ast.Ident]bool)
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if id := calleeIdent(call.Fun); id != nil {
+						called[id] = true
+					}
+				}
+				return true
+			})
+		}
+
+		for id, obj := range pkg.TypesInfo.Uses {
+			fn, ok := obj.(*types.Func)
+			if ok && !called[id] {
+				values[fn.Origin()] = true
+			}
+		}
+	}
+	return values
+}
+
+// calleeIdent returns the identifier naming the function called by an
+// expression, eg. Bar for foo.Bar[T].
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	case *ast.IndexExpr:
+		return calleeIdent(fun.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(fun.X)
+	}
+	return nil
+}
+
+// externalInterfaceMethods returns the methods of the loaded types which
+// implement a non empty interface declared in a dependency.
+func (a *Analyzer) externalInterfaceMethods() map[*types.Func]bool {
+	// by path, test variants shadow the packages imported by the others
+	loaded := make(map[string]bool)
+	for _, pkg := range a.Packages {
+		loaded[pkg.PkgPath] = true
+	}
+
+	// dependencies are walked through their types, they are only
+	// loaded as packages by the SSA algorithms
+	var (
+		ifaces []*types.Interface
+		seen   = make(map[*types.Package]bool)
+		visit  func(pkg *types.Package)
+	)
+	visit = func(pkg *types.Package) {
+		if seen[pkg] {
+			return
+		}
+		seen[pkg] = true
+		for _, imported := range pkg.Imports() {
+			visit(imported)
+		}
+		if loaded[pkg.Path()] {
+			return
+		}
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !tn.Exported() {
+				continue
+			}
+			if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			if iface, ok := tn.Type().Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+				ifaces = append(ifaces, iface)
+			}
+		}
+	}
+	for _, pkg := range a.Packages {
+		if pkg.Types != nil {
+			visit(pkg.Types)
+		}
+	}
+
+	methods := make(map[*types.Func]bool)
+	for _, t := range a.concreteTypes() {
+		for _, iface := range ifaces {
+			if !types.Implements(t, iface) && !types.Implements(types.NewPointer(t), iface) {
+				continue
+			}
+			for i := 0; i < iface.NumMethods(); i++ {
+				m := iface.Method(i)
+				obj, _, _ := types.LookupFieldOrMethod(t, true, m.Pkg(), m.Name())
+				if fn, ok := obj.(*types.Func); ok {
+					methods[fn] = true
+				}
+			}
+		}
+	}
+	return methods
+}
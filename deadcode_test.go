@@ -0,0 +1,112 @@
+package goretriever
+
+import (
+	"strings"
+	"testing"
+)
+
+const deadCodeSource = `package main
+
+import "example.com/m/fmtlike"
+
+type Name string
+
+// String implements an interface of a dependency.
+func (n Name) String() string { return string(n) }
+
+// unused has no caller.
+func (n Name) unused() {}
+
+type handler func()
+
+var handlers = []handler{onStart}
+
+func onStart() { helper() }
+
+func helper() {}
+
+func orphan() { orphanHelper() }
+
+func orphanHelper() {}
+
+// Exported is not an API, main packages can not be imported.
+func Exported() {}
+
+func init() { setup() }
+
+func setup() {}
+
+func main() {
+	fmtlike.Print(Name("x"))
+	func() { handlers[0]() }()
+}
+`
+
+func TestDeadCode(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"fmtlike/fmtlike.go": `package fmtlike
+
+type Stringer interface{ String() string }
+
+func Print(v interface{}) {}
+`,
+		"lib/lib.go": `package lib
+
+func API() { internal() }
+
+func internal() {}
+
+func unused() {}
+`,
+		"app/main.go": deadCodeSource,
+	})
+
+	// fmtlike is a dependency only, the SSA algorithms load it from
+	// source without adding it to the packages. VTA resolves the call
+	// of handlers[0] to onStart only.
+	a, err := NewAnalyzer(dir, []string{"./app", "./lib"}, nil, WithAlgorithm(AlgorithmVTA))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts *DeadCodeOptions
+		dead []string
+	}{
+		{nil, []string{
+			"(example.com/m/app.Name).unused",
+			"example.com/m/app.orphan",
+			"example.com/m/app.orphanHelper",
+			"example.com/m/app.Exported",
+			"example.com/m/lib.API",
+			"example.com/m/lib.internal",
+			"example.com/m/lib.unused",
+		}},
+		{&DeadCodeOptions{ExportedAPI: true}, []string{
+			"(example.com/m/app.Name).unused",
+			"example.com/m/app.orphan",
+			"example.com/m/app.orphanHelper",
+			"example.com/m/app.Exported",
+			"example.com/m/lib.unused",
+		}},
+	}
+	for _, test := range tests {
+		report := a.DeadCode(nil, test.opts)
+
+		var dead []string
+		for _, n := range report.Dead {
+			dead = append(dead, n.ID)
+			if !strings.HasSuffix(n.Pos.Filename, ".go") || n.Pos.Line == 0 {
+				t.Errorf("%s is at %s", n.ID, n.Pos)
+			}
+		}
+		if strings.Join(dead, " ") != strings.Join(test.dead, " ") {
+			t.Errorf("ExportedAPI %t: dead code is %v, want %v", test.opts != nil, dead, test.dead)
+		}
+	}
+
+	report := a.DeadCode(nil, nil)
+	if got := report.String(); !strings.Contains(got, "main.go:11:1: (example.com/m/app.Name).unused is unreachable\n") {
+		t.Errorf("report is %q", got)
+	}
+}
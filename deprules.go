@@ -0,0 +1,177 @@
+package goretriever
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DependencyRule allows or denies the imports from the packages matching
+// From to the packages matching To.
+//
+// Patterns are package paths where ... matches any string and * any
+// string without a slash; like for the go command, a/... matches a too.
+// Patterns match the end of import paths on a path element boundary, so
+// domain/... matches example.com/app/domain and its sub packages.
+type DependencyRule struct {
+	Allow bool
+	From  string
+	To    string
+	// Line is the line of the rule in its file, starting at 1.
+	Line int
+
+	from *regexp.Regexp
+	to   *regexp.Regexp
+}
+
+func (r *DependencyRule) String() string {
+	verb := "deny"
+	if r.Allow {
+		verb = "allow"
+	}
+	return verb + " " + r.From + " -> " + r.To
+}
+
+// DependencyRules are checked in order, the first rule matching an
+// import decides; imports matching no rule are allowed.
+type DependencyRules struct {
+	Rules []*DependencyRule
+}
+
+// DependencyViolation is an import denied by a rule.
+type DependencyViolation struct {
+	Import *PackageImport
+	Rule   *DependencyRule
+}
+
+func (v *DependencyViolation) String() string {
+	b := &strings.Builder{}
+	for _, pos := range v.Import.Positions {
+		fmt.Fprintf(b, "%s: %s imports %s, denied by line %d: %s\n",
+			pos, v.Import.From, v.Import.To, v.Rule.Line, v.Rule)
+	}
+	return b.String()
+}
+
+// compilePattern turns a package pattern into a regular expression.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("^(.*/)?")
+	rest := pattern
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "/..."):
+			// a/... matches a and its sub packages
+			b.WriteString("(/.*)?")
+			rest = rest[len("/..."):]
+		case strings.HasPrefix(rest, "..."):
+			b.WriteString(".*")
+			rest = rest[len("..."):]
+		case rest[0] == '*':
+			b.WriteString("[^/]*")
+			rest = rest[1:]
+		default:
+			i := strings.IndexAny(rest[1:], "*./") + 1
+			if i == 0 {
+				i = len(rest)
+			}
+			b.WriteString(regexp.QuoteMeta(rest[:i]))
+			rest = rest[i:]
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// ParseDependencyRules reads rules, one per line:
+//
+//	# comment
+//	allow example.com/app/domain/... -> example.com/app/domain/...
+//	deny  domain/... -> infra/...
+func ParseDependencyRules(r io.Reader) (*DependencyRules, error) {
+	var (
+		rules   = &DependencyRules{}
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 || fields[2] != "->" {
+			return nil, fmt.Errorf("line %d: expected allow|deny <from> -> <to>", line)
+		}
+
+		rule := &DependencyRule{From: fields[1], To: fields[3], Line: line}
+		switch fields[0] {
+		case "allow":
+			rule.Allow = true
+		case "deny":
+		default:
+			return nil, fmt.Errorf("line %d: unknown verb %q", line, fields[0])
+		}
+
+		var err error
+		if rule.from, err = compilePattern(rule.From); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rule.to, err = compilePattern(rule.To); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadDependencyRules reads the rules file at path.
+func LoadDependencyRules(path string) (*DependencyRules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules, err := ParseDependencyRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Match returns the first rule matching the import of to by from, or nil.
+func (r *DependencyRules) Match(from, to string) *DependencyRule {
+	for _, rule := range r.Rules {
+		if rule.from.MatchString(from) && rule.to.MatchString(to) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Check returns the imports of g denied by the rules.
+func (r *DependencyRules) Check(g *PackageGraph) []*DependencyViolation {
+	var violations []*DependencyViolation
+	for _, imp := range g.Imports {
+		if rule := r.Match(imp.From, imp.To); rule != nil && !rule.Allow {
+			violations = append(violations, &DependencyViolation{Import: imp, Rule: rule})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,107 @@
+package goretriever
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDependencyRulesMatch(t *testing.T) {
+	rules, err := ParseDependencyRules(strings.NewReader(`
+# the domain stays free of infrastructure
+allow domain/... -> domain/...
+deny  domain/... -> infra/...
+deny  github.com/x/proj/cmd/* -> github.com/x/proj/internal/...
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		line     int
+	}{
+		{"github.com/x/proj/domain", "github.com/x/proj/domain/order", 3},
+		{"github.com/x/proj/domain/order", "github.com/x/proj/infra/sql", 4},
+		{"github.com/x/proj/domain", "github.com/x/proj/infra", 4},
+		{"github.com/x/proj/subdomain", "github.com/x/proj/infra", 0},
+		{"github.com/x/proj/domain", "github.com/x/proj/infrastructure", 0},
+		{"github.com/x/proj/cmd/server", "github.com/x/proj/internal/db", 5},
+		{"github.com/x/proj/cmd/server/flags", "github.com/x/proj/internal/db", 0},
+	}
+	for _, test := range tests {
+		var line int
+		if rule := rules.Match(test.from, test.to); rule != nil {
+			line = rule.Line
+		}
+		if line != test.line {
+			t.Errorf("%s -> %s matched line %d, want %d", test.from, test.to, line, test.line)
+		}
+	}
+}
+
+func TestParseDependencyRulesErrors(t *testing.T) {
+	for _, text := range []string{
+		"allow a -> ",
+		"forbid a -> b",
+		"deny a => b",
+	} {
+		if _, err := ParseDependencyRules(strings.NewReader(text)); err == nil {
+			t.Errorf("%q parsed without error", text)
+		}
+	}
+}
+
+func TestDependencyRulesCheck(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"infra/db.go": `package infra
+
+func Open() {}
+`,
+		"domain/order.go": `package domain
+
+import "example.com/m/infra"
+
+func Save() { infra.Open() }
+`,
+		"app/app.go": `package app
+
+import (
+	"example.com/m/domain"
+	"example.com/m/infra"
+)
+
+func Run() {
+	infra.Open()
+	domain.Save()
+}
+`,
+	})
+
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := a.PackageGraph()
+	if len(g.Packages) != 3 || len(g.Imports) != 3 {
+		t.Fatalf("got packages %v and %d imports", g.Packages, len(g.Imports))
+	}
+	if imps := g.ImportersOf("example.com/m/infra"); len(imps) != 2 {
+		t.Errorf("infra is imported %d times, want 2", len(imps))
+	}
+
+	rules, err := ParseDependencyRules(strings.NewReader("deny domain/... -> infra/...\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := rules.Check(g)
+	if len(violations) != 1 {
+		t.Fatalf("got %d violations, want 1", len(violations))
+	}
+	v := violations[0]
+	if v.Import.From != "example.com/m/domain" || v.Import.To != "example.com/m/infra" {
+		t.Errorf("violation of %s -> %s", v.Import.From, v.Import.To)
+	}
+	if len(v.Import.Positions) != 1 || v.Import.Positions[0].Line != 3 {
+		t.Errorf("violation positions are %v, want line 3", v.Import.Positions)
+	}
+}
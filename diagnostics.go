@@ -0,0 +1,132 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageDiagnostics lists the errors reported for one package.
+type PackageDiagnostics struct {
+	PkgPath       string
+	ListErrors    []packages.Error
+	ParseErrors   []packages.Error
+	TypeErrors    []packages.Error
+	UnknownErrors []packages.Error
+}
+
+// Count returns the number of errors of the package.
+func (d *PackageDiagnostics) Count() int {
+	return len(d.ListErrors) + len(d.ParseErrors) + len(d.TypeErrors) + len(d.UnknownErrors)
+}
+
+// Diagnostics is the report of all packages with errors, including
+// dependencies of the requested packages.
+type Diagnostics struct {
+	Packages []*PackageDiagnostics
+}
+
+// HasErrors reports whether any package has errors.
+func (d *Diagnostics) HasErrors() bool {
+	return len(d.Packages) > 0
+}
+
+// Count returns the number of errors of all packages.
+func (d *Diagnostics) Count() int {
+	var n int
+	for _, p := range d.Packages {
+		n += p.Count()
+	}
+	return n
+}
+
+func (d *Diagnostics) String() string {
+	b := &strings.Builder{}
+	for _, p := range d.Packages {
+		for _, errs := range [][]packages.Error{p.ListErrors, p.ParseErrors, p.TypeErrors, p.UnknownErrors} {
+			for _, err := range errs {
+				fmt.Fprintf(b, "%s: %s\n", p.PkgPath, err)
+			}
+		}
+	}
+	return b.String()
+}
+
+// LoadError is returned when the requested packages have errors and
+// the FailOnError policy is in use. Its diagnostics only list the
+// requested packages, errors of dependencies alone do not fail.
+type LoadError struct {
+	Diagnostics *Diagnostics
+}
+
+func (e *LoadError) Error() string {
+	d := e.Diagnostics
+	if !d.HasErrors() {
+		return "failed to load packages"
+	}
+
+	var first packages.Error
+	for _, errs := range [][]packages.Error{
+		d.Packages[0].ListErrors,
+		d.Packages[0].ParseErrors,
+		d.Packages[0].TypeErrors,
+		d.Packages[0].UnknownErrors,
+	} {
+		if len(errs) > 0 {
+			first = errs[0]
+			break
+		}
+	}
+	return fmt.Sprintf("%d errors in %d packages, first: %s: %s",
+		d.Count(), len(d.Packages), d.Packages[0].PkgPath, first)
+}
+
+// newDiagnostics collects the errors of pkgs and of their dependencies.
+func newDiagnostics(pkgs []*packages.Package) *Diagnostics {
+	d := &Diagnostics{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pd := newPackageDiagnostics(pkg); pd != nil {
+			d.Packages = append(d.Packages, pd)
+		}
+	})
+	return d
+}
+
+// rootDiagnostics collects the errors of pkgs, without their
+// dependencies.
+func rootDiagnostics(pkgs []*packages.Package) *Diagnostics {
+	d := &Diagnostics{}
+	for _, pkg := range pkgs {
+		if pd := newPackageDiagnostics(pkg); pd != nil {
+			d.Packages = append(d.Packages, pd)
+		}
+	}
+	return d
+}
+
+// newPackageDiagnostics sorts the errors of pkg by kind, it returns nil
+// when pkg has none.
+func newPackageDiagnostics(pkg *packages.Package) *PackageDiagnostics {
+	if len(pkg.Errors) == 0 {
+		return nil
+	}
+
+	pd := &PackageDiagnostics{PkgPath: pkg.PkgPath}
+	if pd.PkgPath == "" {
+		pd.PkgPath = pkg.ID
+	}
+	for _, err := range pkg.Errors {
+		switch err.Kind {
+		case packages.ListError:
+			pd.ListErrors = append(pd.ListErrors, err)
+		case packages.ParseError:
+			pd.ParseErrors = append(pd.ParseErrors, err)
+		case packages.TypeError:
+			pd.TypeErrors = append(pd.TypeErrors, err)
+		default:
+			pd.UnknownErrors = append(pd.UnknownErrors, err)
+		}
+	}
+	return pd
+}
@@ -0,0 +1,79 @@
+package goretriever
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func writeBrokenModule(t *testing.T) string {
+	return writeModule(t, map[string]string{
+		"good/good.go": `package good
+
+func Hello() int { return 1 }
+`,
+		"typed/typed.go": `package typed
+
+func Broken() int { return "one" }
+
+func Fine() int { return 2 }
+`,
+		"syntax/syntax.go": `package syntax
+
+func Unclosed( {
+`,
+	})
+}
+
+func TestContinueOnErrorByDefault(t *testing.T) {
+	dir := writeBrokenModule(t)
+
+	var d Diagnostics
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil, WithDiagnostics(&d))
+	if err != nil {
+		t.Fatalf("BuildCallGraph failed by default: %v", err)
+	}
+	if graph.Node("example.com/m/typed.Fine") == nil {
+		t.Error("the partial graph lacks typed.Fine")
+	}
+
+	if !d.HasErrors() {
+		t.Fatal("no diagnostics reported")
+	}
+	byPath := make(map[string]*PackageDiagnostics)
+	for _, p := range d.Packages {
+		byPath[p.PkgPath] = p
+	}
+	if p := byPath["example.com/m/typed"]; p == nil || len(p.TypeErrors) != 1 {
+		t.Errorf("typed diagnostics are %+v, want one type error", p)
+	}
+	if p := byPath["example.com/m/syntax"]; p == nil || len(p.ParseErrors) == 0 {
+		t.Errorf("syntax diagnostics are %+v, want parse errors", p)
+	}
+	if p := byPath["example.com/m/good"]; p != nil {
+		t.Errorf("good has diagnostics %+v", p)
+	}
+	if !strings.Contains(d.String(), "example.com/m/typed: ") {
+		t.Errorf("report does not name typed:\n%s", d.String())
+	}
+}
+
+func TestFailOnError(t *testing.T) {
+	dir := writeBrokenModule(t)
+
+	_, err := FindInterfaces(dir, []string{"./typed"}, nil, WithErrorPolicy(FailOnError))
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("error is %v, want a *LoadError", err)
+	}
+	if n := len(loadErr.Diagnostics.Packages); n != 1 {
+		t.Errorf("load error lists %d packages, want 1", n)
+	}
+	if !strings.Contains(err.Error(), " errors in 1 packages, first: example.com/m/typed: ") {
+		t.Errorf("unexpected message %q", err)
+	}
+
+	if _, err := FindInterfaces(dir, []string{"./good"}, nil, WithErrorPolicy(FailOnError)); err != nil {
+		t.Errorf("good package failed: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package goretriever
+
+import (
+	"go/types"
+	"sort"
+)
+
+// interfaceOf returns the interface declaring fn, or nil when fn is not
+// an interface method.
+func interfaceOf(fn *types.Func) *types.Interface {
+	if fn == nil {
+		return nil
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	if named, ok := recv.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil
+	}
+	iface, _ := recv.Type().Underlying().(*types.Interface)
+	return iface
+}
+
+// concreteTypes returns the non generic, non interface named types
+// declared in the loaded packages, ordered by name.
+func (a *Analyzer) concreteTypes() []types.Type {
+	if a.concrete != nil {
+		return a.concrete
+	}
+
+	seen := make(map[types.Type]bool)
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, def := range pkg.TypesInfo.Defs {
+			tn, ok := def.(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+			seen[named] = true
+		}
+	}
+
+	concrete := make([]types.Type, 0, len(seen))
+	for t := range seen {
+		concrete = append(concrete, t)
+	}
+	sort.Slice(concrete, func(i, j int) bool { return concrete[i].String() < concrete[j].String() })
+	a.concrete = concrete
+	return concrete
+}
+
+// resolveDynamicCalls links the interface methods of callGraph to the
+// methods of the loaded types implementing them, with dynamic edges.
+// Callers of the interface method keep calling it, so the interface
+// method is the intermediate node of every call made through it.
+func (a *Analyzer) resolveDynamicCalls(callGraph *CallGraph) {
+	concrete := a.concreteTypes()
+
+	for _, n := range callGraph.Nodes() {
+		iface := interfaceOf(n.Func)
+		if iface == nil {
+			continue
+		}
+
+		for _, t := range concrete {
+			if !types.Implements(t, iface) && !types.Implements(types.NewPointer(t), iface) {
+				continue
+			}
+			obj, _, _ := types.LookupFieldOrMethod(t, true, n.Func.Pkg(), n.Func.Name())
+			m, ok := obj.(*types.Func)
+			if !ok {
+				continue
+			}
+			callGraph.AddDynamicEdge(n, callGraph.AddFunc(m))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package goretriever
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestResolveDynamicCalls(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"shape/shape.go": `package shape
+
+type Shape interface{ Area() int }
+
+func Total(shapes []Shape) (n int) {
+	for _, s := range shapes {
+		n += s.Area()
+	}
+	return n
+}
+`,
+		"square/square.go": `package square
+
+type Square struct{ Side int }
+
+func (s *Square) Area() int { return s.Side * s.Side }
+
+// Tile gets Area from the embedded Square.
+type Tile struct{ *Square }
+`,
+		"rect/rect.go": `package rect
+
+type Rect struct{ W, H int }
+
+func (r Rect) Area() int { return r.W * r.H }
+
+// Line has no Area method.
+type Line struct{ Len int }
+
+func (l Line) Length() int { return l.Len }
+`,
+	})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const area = "(example.com/m/shape.Shape).Area"
+	iface := graph.Node(area)
+	if iface == nil {
+		t.Fatalf("no node for the interface method %s", area)
+	}
+
+	var edge *CallEdge
+	for _, e := range iface.In {
+		if e.Caller.ID == "example.com/m/shape.Total" {
+			edge = e
+		}
+	}
+	if edge == nil || edge.Dynamic || len(edge.Sites) != 1 {
+		t.Fatalf("Total -> %s is %+v, want one static call site", area, edge)
+	}
+
+	var callees []string
+	for _, e := range iface.Out {
+		if !e.Dynamic {
+			t.Errorf("edge to %s is not dynamic", e.Callee.ID)
+		}
+		if len(e.Sites) != 0 {
+			t.Errorf("dynamic edge to %s has sites %v", e.Callee.ID, e.Sites)
+		}
+		callees = append(callees, e.Callee.ID)
+	}
+	sort.Strings(callees)
+
+	// Tile reaches the method of the embedded Square, which already
+	// has an edge
+	want := []string{
+		"(*example.com/m/square.Square).Area",
+		"(example.com/m/rect.Rect).Area",
+	}
+	if len(callees) != len(want) {
+		t.Fatalf("callees of %s are %v, want %v", area, callees, want)
+	}
+	for i := range want {
+		if callees[i] != want[i] {
+			t.Errorf("callees of %s are %v, want %v", area, callees, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package goretriever
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DocFormat selects the output format of the documentation exporter.
+type DocFormat int
+
+const (
+	DocMarkdown DocFormat = iota
+	DocHTML
+)
+
+// DocOptions controls how documentation is rendered.
+type DocOptions struct {
+	// Root is stripped from source file names so links stay relative.
+	Root string
+	// SourceLink builds a link to the source lines [beg, end] of file.
+	// When nil, file names are printed relative to Root without a link.
+	SourceLink func(file string, beg, end int) string
+	// NoSource disables the source snippets.
+	NoSource bool
+}
+
+type docEntry struct {
+	Anchor  string
+	Title   string
+	Doc     string
+	Code    string
+	File    string
+	Link    string
+	Methods []*docEntry
+}
+
+type docPackage struct {
+	Name      string
+	Dir       string
+	Types     []*docEntry
+	Functions []*docEntry
+}
+
+func newDocEntry(anchor, title, doc, code, file string, line int, opts *DocOptions) *docEntry {
+	e := &docEntry{
+		Anchor: anchor,
+		Title:  title,
+		Doc:    strings.TrimSpace(doc),
+	}
+	if !opts.NoSource {
+		e.Code = strings.TrimRight(strings.ReplaceAll(code, "\r", ""), "\n")
+	}
+	if file == "" {
+		return e
+	}
+
+	rel := file
+	if opts.Root != "" {
+		if r, err := filepath.Rel(opts.Root, file); err == nil {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
+	end := line + strings.Count(strings.TrimRight(code, "\r\n"), "\n")
+
+	e.File = fmt.Sprintf("%s:%d", rel, line)
+	if opts.SourceLink != nil {
+		e.Link = opts.SourceLink(rel, line, end)
+	}
+	return e
+}
+
+func newDocPackage(pkg *Package, opts *DocOptions) *docPackage {
+	d := &docPackage{
+		Name: pkg.Name,
+		Dir:  pkg.Dir,
+	}
+
+	structNames := make([]string, 0, len(pkg.Structs))
+	for name := range pkg.Structs {
+		structNames = append(structNames, name)
+	}
+	sort.Strings(structNames)
+
+	for _, name := range structNames {
+		s := pkg.Structs[name]
+		e := newDocEntry("type-"+name, "type "+name, s.Doc, s.Code, s.File, s.Line, opts)
+
+		methodNames := make([]string, 0, len(s.Methods))
+		for m := range s.Methods {
+			methodNames = append(methodNames, m)
+		}
+		sort.Strings(methodNames)
+
+		for _, m := range methodNames {
+			f := s.Methods[m]
+			recv := f.Recv
+			if recv == "" {
+				recv = name
+			}
+			e.Methods = append(e.Methods,
+				newDocEntry("method-"+name+"-"+m, "func ("+recv+") "+m, f.Doc, f.Code, f.File, f.Line, opts))
+		}
+		d.Types = append(d.Types, e)
+	}
+
+	funcNames := make([]string, 0, len(pkg.Functions))
+	for name := range pkg.Functions {
+		funcNames = append(funcNames, name)
+	}
+	sort.Strings(funcNames)
+
+	for _, name := range funcNames {
+		f := pkg.Functions[name]
+		d.Functions = append(d.Functions,
+			newDocEntry("func-"+name, "func "+name, f.Doc, f.Code, f.File, f.Line, opts))
+	}
+
+	return d
+}
+
+func writeMarkdownEntry(b *strings.Builder, level string, e *docEntry) {
+	fmt.Fprintf(b, "<a id=\"%s\"></a>\n\n%s %s\n\n", e.Anchor, level, e.Title)
+	if e.Doc != "" {
+		b.WriteString(e.Doc)
+		b.WriteString("\n\n")
+	}
+	if e.Code != "" {
+		fence := "```"
+		for strings.Contains(e.Code, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(b, "%sgo\n%s\n%s\n\n", fence, e.Code, fence)
+	}
+	switch {
+	case e.Link != "":
+		fmt.Fprintf(b, "[%s](%s)\n\n", e.File, e.Link)
+	case e.File != "":
+		fmt.Fprintf(b, "`%s`\n\n", e.File)
+	}
+}
+
+// WriteMarkdown renders the documentation of pkg as a single Markdown page.
+func WriteMarkdown(w io.Writer, pkg *Package, opts *DocOptions) error {
+	if pkg == nil {
+		return errors.New("invalid input")
+	}
+	if opts == nil {
+		opts = &DocOptions{}
+	}
+
+	d := newDocPackage(pkg, opts)
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "# package %s\n\n", d.Name)
+	if d.Dir != "" {
+		fmt.Fprintf(b, "`%s`\n\n", filepath.ToSlash(d.Dir))
+	}
+
+	b.WriteString("## Index\n\n")
+	for _, t := range d.Types {
+		fmt.Fprintf(b, "- [%s](#%s)\n", t.Title, t.Anchor)
+		for _, m := range t.Methods {
+			fmt.Fprintf(b, "  - [%s](#%s)\n", m.Title, m.Anchor)
+		}
+	}
+	for _, f := range d.Functions {
+		fmt.Fprintf(b, "- [%s](#%s)\n", f.Title, f.Anchor)
+	}
+	b.WriteString("\n")
+
+	if len(d.Types) > 0 {
+		b.WriteString("## Types\n\n")
+		for _, t := range d.Types {
+			writeMarkdownEntry(b, "###", t)
+			for _, m := range t.Methods {
+				writeMarkdownEntry(b, "####", m)
+			}
+		}
+	}
+
+	if len(d.Functions) > 0 {
+		b.WriteString("## Functions\n\n")
+		for _, f := range d.Functions {
+			writeMarkdownEntry(b, "###", f)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlPackageTemplate = template.Must(template.New("package").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>package {{.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.src { font-size: small; color: #666; }
+</style>
+</head>
+<body>
+<p><a href="index.html">Packages</a></p>
+<h1>package {{.Name}}</h1>
+{{if .Dir}}<p><code>{{.Dir}}</code></p>{{end}}
+<h2>Index</h2>
+<ul>
+{{range .Types}}<li><a href="#{{.Anchor}}">{{.Title}}</a>{{if .Methods}}<ul>{{range .Methods}}<li><a href="#{{.Anchor}}">{{.Title}}</a></li>{{end}}</ul>{{end}}</li>
+{{end}}{{range .Functions}}<li><a href="#{{.Anchor}}">{{.Title}}</a></li>
+{{end}}</ul>
+{{if .Types}}<h2>Types</h2>
+{{range .Types}}{{template "entry" .}}{{range .Methods}}{{template "entry" .}}{{end}}{{end}}{{end}}
+{{if .Functions}}<h2>Functions</h2>
+{{range .Functions}}{{template "entry" .}}{{end}}{{end}}
+</body>
+</html>
+{{define "entry"}}<h3 id="{{.Anchor}}">{{.Title}}</h3>
+{{if .Doc}}<p>{{.Doc}}</p>{{end}}
+{{if .Code}}<pre><code>{{.Code}}</code></pre>{{end}}
+{{if .Link}}<p class="src"><a href="{{.Link}}">{{.File}}</a></p>{{else if .File}}<p class="src">{{.File}}</p>{{end}}
+{{end}}`))
+
+var htmlIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Packages</title>
+</head>
+<body>
+<h1>Packages</h1>
+<ul>
+{{range .}}<li><a href="{{.File}}">{{.Name}}</a>{{if .Dir}} <code>{{.Dir}}</code>{{end}}</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// WriteHTML renders the documentation of pkg as a single HTML page.
+func WriteHTML(w io.Writer, pkg *Package, opts *DocOptions) error {
+	if pkg == nil {
+		return errors.New("invalid input")
+	}
+	if opts == nil {
+		opts = &DocOptions{}
+	}
+	return htmlPackageTemplate.Execute(w, newDocPackage(pkg, opts))
+}
+
+// docFileName builds a unique file name for every package, packages
+// sharing a name are told apart by their directory.
+func docFileName(pkg *Package, root string, ext string, used map[string]bool) string {
+	name := pkg.Name
+	if pkg.Dir != "" {
+		dir := pkg.Dir
+		if root != "" {
+			if r, err := filepath.Rel(root, dir); err == nil {
+				dir = r
+			}
+		}
+		dir = strings.TrimPrefix(path.Clean(filepath.ToSlash(dir)), "/")
+		if dir != "." {
+			// directories out of root have their .. elements named up
+			elems := strings.Split(dir, "/")
+			for i, elem := range elems {
+				if elem == ".." {
+					elems[i] = "up"
+				}
+			}
+			name = strings.Join(elems, "_") + "_" + pkg.Name
+		}
+	}
+
+	file := name + ext
+	for i := 2; used[file]; i++ {
+		file = fmt.Sprintf("%s_%d%s", name, i, ext)
+	}
+	used[file] = true
+	return file
+}
+
+// ExportDocs writes the documentation of pkgs into dir, one file per
+// package. For DocHTML an index.html listing all packages is written too.
+func ExportDocs(pkgs []*Package, dir string, format DocFormat, opts *DocOptions) error {
+	if opts == nil {
+		opts = &DocOptions{}
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	type indexEntry struct {
+		Name string
+		Dir  string
+		File string
+	}
+
+	var (
+		ext   = ".md"
+		write = WriteMarkdown
+		used  = make(map[string]bool)
+		index []indexEntry
+	)
+	switch format {
+	case DocMarkdown:
+	case DocHTML:
+		ext = ".html"
+		write = WriteHTML
+		used["index.html"] = true
+	default:
+		return fmt.Errorf("unknown doc format %d", format)
+	}
+
+	for _, pkg := range pkgs {
+		name := docFileName(pkg, opts.Root, ext, used)
+
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+		err = write(file, pkg, opts)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		index = append(index, indexEntry{
+			Name: pkg.Name,
+			Dir:  filepath.ToSlash(pkg.Dir),
+			File: name,
+		})
+	}
+
+	if format != DocHTML {
+		return nil
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, "index.html"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return htmlIndexTemplate.Execute(file, index)
+}
@@ -0,0 +1,118 @@
+package goretriever
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const docsSource = `package geo
+
+// Point is a position on the plane.
+type Point struct {
+	X, Y float64
+}
+
+// Move shifts p by dx and dy.
+func (p *Point) Move(dx, dy float64) {
+	p.X += dx
+	p.Y += dy
+}
+
+// Origin returns the point <0, 0>.
+func Origin() Point { return Point{} }
+`
+
+func TestWriteMarkdown(t *testing.T) {
+	pkg, err := ParseString("geo", docsSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	opts := &DocOptions{
+		SourceLink: func(file string, beg, end int) string {
+			return fmt.Sprintf("https://src.example.com/%s#L%d-L%d", file, beg, end)
+		},
+	}
+	if err := WriteMarkdown(&b, pkg, opts); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# package geo\n",
+		"- [type Point](#type-Point)\n",
+		"  - [func (*Point) Move](#method-Point-Move)\n",
+		"- [func Origin](#func-Origin)\n",
+		"Point is a position on the plane.\n",
+		"Move shifts p by dx and dy.\n",
+		"```go\nfunc Origin() Point { return Point{} }\n```\n",
+		"[geo:9](https://src.example.com/geo#L9-L12)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown lacks %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHTMLEscapes(t *testing.T) {
+	pkg, err := ParseString("geo", docsSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := WriteHTML(&b, pkg, &DocOptions{NoSource: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	if !strings.Contains(out, "Origin returns the point &lt;0, 0&gt;.") {
+		t.Errorf("doc comment is not escaped\n%s", out)
+	}
+	if strings.Contains(out, "<pre>") {
+		t.Errorf("NoSource still renders source\n%s", out)
+	}
+	if !strings.Contains(out, `<h3 id="method-Point-Move">func (*Point) Move</h3>`) {
+		t.Errorf("method heading missing\n%s", out)
+	}
+}
+
+func TestExportDocs(t *testing.T) {
+	root := t.TempDir()
+	pkgs := []*Package{
+		{Name: "util", Dir: filepath.Join(root, "a", "util")},
+		{Name: "util", Dir: filepath.Join(root, "b", "util")},
+		{Name: "util", Dir: filepath.Join(filepath.Dir(root), "util")},
+		{Name: "main"},
+	}
+
+	out := t.TempDir()
+	if err := ExportDocs(pkgs, out, DocHTML, &DocOptions{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{
+		"index.html",
+		"a_util_util.html",
+		"b_util_util.html",
+		"up_util_util.html",
+		"main.html",
+	} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("%s was not written: %v", name, err)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `<a href="b_util_util.html">util</a>`) {
+		t.Errorf("index does not link b/util\n%s", index)
+	}
+}
@@ -0,0 +1,302 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// FieldAccessKind tells how a struct field is used.
+type FieldAccessKind int
+
+const (
+	// FieldRead reads the field.
+	FieldRead FieldAccessKind = iota
+	// FieldWrite assigns the field, x.f++ and x.f += y also read it.
+	FieldWrite
+	// FieldInit sets the field in a composite literal.
+	FieldInit
+	// FieldAddr takes the address of the field, explicitly or to call a
+	// method with a pointer receiver, which may write it.
+	FieldAddr
+)
+
+func (k FieldAccessKind) String() string {
+	switch k {
+	case FieldRead:
+		return "read"
+	case FieldWrite:
+		return "write"
+	case FieldInit:
+		return "init"
+	case FieldAddr:
+		return "addr"
+	}
+	return fmt.Sprintf("FieldAccessKind(%d)", int(k))
+}
+
+// FieldAccess is a use of a struct field.
+type FieldAccess struct {
+	Kind FieldAccessKind
+	// Func is the id of the enclosing function, as in the call graph.
+	Func string
+	Pos  token.Position
+}
+
+// FieldUsage lists the accesses to a struct field.
+type FieldUsage struct {
+	// ID is the struct id followed by the field name, like
+	// example.com/shop.Order.Status.
+	ID string
+	// Struct is the id of the named struct type, or the struct type
+	// itself for anonymous structs.
+	Struct string
+	Name   string
+	Type   string
+	Pos    token.Position
+	// Accesses are ordered by position.
+	Accesses []*FieldAccess
+}
+
+// Readers returns the ids of the functions reading the field, ordered.
+func (u *FieldUsage) Readers() []string {
+	return u.funcs(FieldRead)
+}
+
+// Writers returns the ids of the functions writing the field, setting
+// it in a composite literal or taking its address, ordered.
+func (u *FieldUsage) Writers() []string {
+	return u.funcs(FieldWrite, FieldInit, FieldAddr)
+}
+
+func (u *FieldUsage) funcs(kinds ...FieldAccessKind) []string {
+	var (
+		ids  []string
+		seen = make(map[string]bool)
+	)
+	for _, access := range u.Accesses {
+		for _, kind := range kinds {
+			if access.Kind == kind && !seen[access.Func] {
+				seen[access.Func] = true
+				ids = append(ids, access.Func)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// FieldIndex maps the struct fields used by the loaded packages to
+// their accesses.
+type FieldIndex struct {
+	// Fields are ordered by id.
+	Fields []*FieldUsage
+
+	byID map[string]*FieldUsage
+}
+
+// Field returns the field with the given id, or nil.
+func (ix *FieldIndex) Field(id string) *FieldUsage {
+	return ix.byID[id]
+}
+
+// FieldsOf returns the used fields of the struct with the given id.
+func (ix *FieldIndex) FieldsOf(structID string) []*FieldUsage {
+	var fields []*FieldUsage
+	for _, u := range ix.Fields {
+		if u.Struct == structID {
+			fields = append(fields, u)
+		}
+	}
+	return fields
+}
+
+// Find returns the fields whose id is name or ends with .name, so that
+// Order.Status finds example.com/shop.Order.Status.
+func (ix *FieldIndex) Find(name string) []*FieldUsage {
+	var fields []*FieldUsage
+	for _, u := range ix.Fields {
+		if u.ID == name || strings.HasSuffix(u.ID, "."+name) {
+			fields = append(fields, u)
+		}
+	}
+	return fields
+}
+
+// typeID names a type after its declaration, types which are not named
+// are named after their structure.
+func typeID(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Origin().Obj()
+		if obj.Pkg() == nil {
+			return obj.Name()
+		}
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+	return t.String()
+}
+
+func (ix *FieldIndex) add(a *Analyzer, owner types.Type, field *types.Var, access *FieldAccess) {
+	field = field.Origin()
+	id := typeID(owner) + "." + field.Name()
+	u, ok := ix.byID[id]
+	if !ok {
+		u = &FieldUsage{
+			ID:     id,
+			Struct: typeID(owner),
+			Name:   field.Name(),
+			Type:   field.Type().String(),
+			Pos:    a.Fset.Position(field.Pos()),
+		}
+		ix.byID[id] = u
+		ix.Fields = append(ix.Fields, u)
+	}
+	u.Accesses = append(u.Accesses, access)
+}
+
+// fieldOwner returns the struct declaring the field selected by sel,
+// following embedded fields.
+func fieldOwner(sel *types.Selection) types.Type {
+	t := sel.Recv()
+	index := sel.Index()
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		t = t.Underlying().(*types.Struct).Field(i).Type()
+	}
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return t
+}
+
+// selectorKinds tells how the field selected by sel is used from its
+// ancestors.
+func selectorKinds(info *types.Info, sel *ast.SelectorExpr, field *types.Var, stack []ast.Node) []FieldAccessKind {
+	var (
+		child  ast.Node = sel
+		parent ast.Node
+	)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if _, ok := stack[i].(*ast.ParenExpr); !ok {
+			parent = stack[i]
+			break
+		}
+		child = stack[i]
+	}
+
+	switch parent := parent.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs != child {
+				continue
+			}
+			if parent.Tok == token.ASSIGN || parent.Tok == token.DEFINE {
+				return []FieldAccessKind{FieldWrite}
+			}
+			return []FieldAccessKind{FieldRead, FieldWrite}
+		}
+	case *ast.IncDecStmt:
+		return []FieldAccessKind{FieldRead, FieldWrite}
+	case *ast.RangeStmt:
+		if parent.Key == child || parent.Value == child {
+			return []FieldAccessKind{FieldWrite}
+		}
+	case *ast.UnaryExpr:
+		if parent.Op == token.AND {
+			return []FieldAccessKind{FieldAddr}
+		}
+	case *ast.SelectorExpr:
+		// x.f.M() takes the address of x.f when M has a pointer receiver
+		if s, ok := info.Selections[parent]; ok && s.Kind() == types.MethodVal {
+			_, isPtr := field.Type().Underlying().(*types.Pointer)
+			recv := s.Obj().(*types.Func).Type().(*types.Signature).Recv()
+			if _, ptrRecv := recv.Type().(*types.Pointer); ptrRecv && !isPtr && !types.IsInterface(field.Type()) {
+				return []FieldAccessKind{FieldAddr}
+			}
+		}
+	}
+	return []FieldAccessKind{FieldRead}
+}
+
+// FieldIndex indexes the reads and writes of struct fields made by the
+// functions of the loaded packages, from selections and composite
+// literals. Accesses to promoted fields are accesses to the embedded
+// struct field.
+func (a *Analyzer) FieldIndex() *FieldIndex {
+	ix := &FieldIndex{byID: make(map[string]*FieldUsage)}
+
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+		w := newFuncWalker(pkg)
+
+		for _, file := range pkg.Syntax {
+			w.walkFile(file, func(n ast.Node, stack []ast.Node, fn string) {
+				switch n := n.(type) {
+				case *ast.SelectorExpr:
+					sel, ok := info.Selections[n]
+					if !ok || sel.Kind() != types.FieldVal {
+						return
+					}
+					field := sel.Obj().(*types.Var)
+					for _, kind := range selectorKinds(info, n, field, stack) {
+						ix.add(a, fieldOwner(sel), field, &FieldAccess{
+							Kind: kind,
+							Func: fn,
+							Pos:  a.Fset.Position(n.Sel.Pos()),
+						})
+					}
+
+				case *ast.CompositeLit:
+					t := info.TypeOf(n)
+					if t == nil {
+						return
+					}
+					if ptr, ok := t.Underlying().(*types.Pointer); ok {
+						t = ptr.Elem()
+					}
+					st, ok := t.Underlying().(*types.Struct)
+					if !ok {
+						return
+					}
+
+					for i, elt := range n.Elts {
+						var field *types.Var
+						pos := elt.Pos()
+						if kv, ok := elt.(*ast.KeyValueExpr); ok {
+							if key, ok := kv.Key.(*ast.Ident); ok {
+								field, _ = info.Uses[key].(*types.Var)
+							}
+						} else if i < st.NumFields() {
+							field = st.Field(i)
+						}
+						if field == nil {
+							continue
+						}
+						ix.add(a, t, field, &FieldAccess{
+							Kind: FieldInit,
+							Func: fn,
+							Pos:  a.Fset.Position(pos),
+						})
+					}
+				}
+			})
+		}
+	}
+
+	sort.Slice(ix.Fields, func(i, j int) bool { return ix.Fields[i].ID < ix.Fields[j].ID })
+	for _, u := range ix.Fields {
+		sort.SliceStable(u.Accesses, func(i, j int) bool { return positionLess(u.Accesses[i].Pos, u.Accesses[j].Pos) })
+	}
+	return ix
+}
@@ -0,0 +1,118 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const shopSource = `package shop
+
+type Status int
+
+func (s *Status) Set(v Status) { *s = v }
+
+type Audit struct{ By string }
+
+type Order struct {
+	ID     int
+	Status Status
+	Audit
+	items []int
+}
+
+var defaultOrder = Order{ID: 1}
+
+func New(id int) *Order {
+	return &Order{ID: id, Status: 1}
+}
+
+func (o *Order) Cancel() {
+	o.Status = 3
+	o.By = "system"
+}
+
+func (o *Order) Touch() {
+	o.Status++
+	o.Status.Set(2)
+	p := &o.ID
+	_ = p
+}
+
+func Total(o Order) int {
+	n := 0
+	for _, it := range o.items {
+		n += it
+	}
+	return n + o.ID
+}
+
+func Pair() Audit { return Audit{"admin"} }
+
+func Point() int {
+	p := struct{ X, Y int }{1, 2}
+	return p.X
+}
+`
+
+func TestFieldIndex(t *testing.T) {
+	dir := writeModule(t, map[string]string{"shop/shop.go": shopSource})
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := a.FieldIndex()
+
+	accesses := func(id string) string {
+		u := ix.Field(id)
+		if u == nil {
+			return "<nil>"
+		}
+		var s []string
+		for _, access := range u.Accesses {
+			s = append(s, fmt.Sprintf("%d:%s:%s", access.Pos.Line, access.Kind,
+				strings.TrimPrefix(access.Func, "example.com/m/shop.")))
+		}
+		return strings.Join(s, " ")
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"example.com/m/shop.Order.Status",
+			"19:init:New 23:write:(*example.com/m/shop.Order).Cancel " +
+				"28:read:(*example.com/m/shop.Order).Touch 28:write:(*example.com/m/shop.Order).Touch " +
+				"29:addr:(*example.com/m/shop.Order).Touch"},
+		// the initializers of package variables belong to the package
+		// initializer
+		{"example.com/m/shop.Order.ID",
+			"16:init:init 19:init:New 30:addr:(*example.com/m/shop.Order).Touch 39:read:Total"},
+		// promoted fields are fields of the embedded struct
+		{"example.com/m/shop.Audit.By",
+			"24:write:(*example.com/m/shop.Order).Cancel 42:init:Pair"},
+		{"example.com/m/shop.Order.items", "36:read:Total"},
+		{"struct{X int; Y int}.X", "45:init:Point 46:read:Point"},
+		{"struct{X int; Y int}.Y", "45:init:Point"},
+	}
+	for _, test := range tests {
+		if got := accesses(test.id); got != test.want {
+			t.Errorf("%s: accesses are\n%s\nwant\n%s", test.id, got, test.want)
+		}
+	}
+
+	status := ix.Find("Order.Status")
+	if len(status) != 1 || status[0].Struct != "example.com/m/shop.Order" || status[0].Type != "example.com/m/shop.Status" {
+		t.Fatalf("Order.Status finds %v", status)
+	}
+	if got := strings.Join(status[0].Readers(), " "); got != "(*example.com/m/shop.Order).Touch" {
+		t.Errorf("readers are %s", got)
+	}
+	want := "(*example.com/m/shop.Order).Cancel (*example.com/m/shop.Order).Touch example.com/m/shop.New"
+	if got := strings.Join(status[0].Writers(), " "); got != want {
+		t.Errorf("writers are %s, want %s", got, want)
+	}
+	if fields := ix.FieldsOf("example.com/m/shop.Order"); len(fields) != 3 {
+		t.Errorf("fields of Order are %v", fields)
+	}
+}
@@ -10,6 +10,11 @@ type Function struct {
 	Name       string
 	Code       string
 	Defination string
+	Recv       string // receiver type of methods as declared, *T or T
+	Doc        string
+	File       string
+	Line       int
+	Metrics    *Metrics
 	Struct     *Struct `json:"-"`
 	Beg        int     `json:"-"`
 	End        int     `json:"-"`
@@ -31,9 +36,11 @@ func newFunctionFromDecl(reader io.ReaderAt, decl *ast.FuncDecl, fileSet *token.
 		case *ast.StarExpr:
 			if ident, ok := t.X.(*ast.Ident); ok {
 				structName = ident.Name
+				f.Recv = "*" + ident.Name
 			}
 		case *ast.Ident:
 			structName = t.Name
+			f.Recv = t.Name
 		}
 	}
 
@@ -50,6 +57,12 @@ func newFunctionFromDecl(reader io.ReaderAt, decl *ast.FuncDecl, fileSet *token.
 	f.Code = code
 	f.Beg = beg
 	f.End = end
+	f.Metrics = newMetrics(fileSet, decl, decl.Type, decl.Body)
+
+	if decl.Doc != nil {
+		f.Doc = decl.Doc.Text()
+	}
+	f.File, f.Line = getOffsetPosition(decl, beg, fileSet)
 
 	return structName, f
 }
@@ -0,0 +1,93 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// funcWalker visits the syntax of a package knowing the function
+// enclosing every node. It names the functions of the call graph built
+// with AlgorithmAST: closures are parent$1, package level variables are
+// initialized by pkg.init and init functions are pkg.init#1...
+type funcWalker struct {
+	pkg      *packages.Package
+	inits    int
+	closures map[string]int
+	// enter, when set, is called with the *ast.FuncDecl or *ast.FuncLit
+	// of every function before its nodes are visited, parent is the
+	// enclosing function of closures.
+	enter func(node ast.Node, fn, parent string)
+}
+
+func newFuncWalker(pkg *packages.Package) *funcWalker {
+	return &funcWalker{pkg: pkg, closures: make(map[string]int)}
+}
+
+// walkFile calls visit for the nodes of the functions and of the type,
+// constant and variable declarations of file, with their ancestors and
+// the id of the enclosing function, "" for types and constants. Closures
+// of types and constants are never called and are not named. Files must
+// be walked in the order of pkg.Syntax.
+func (w *funcWalker) walkFile(file *ast.File, visit func(n ast.Node, stack []ast.Node, fn string)) {
+	for _, decl := range file.Decls {
+		switch xDecl := decl.(type) {
+		case *ast.FuncDecl:
+			fn, ok := w.pkg.TypesInfo.Defs[xDecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+
+			id := FuncID(fn)
+			if xDecl.Recv == nil && xDecl.Name.Name == "init" {
+				w.inits++
+				id = fmt.Sprintf("%s.init#%d", w.pkg.PkgPath, w.inits)
+			}
+			if w.enter != nil {
+				w.enter(xDecl, id, "")
+			}
+			if xDecl.Recv != nil {
+				w.walk(xDecl.Recv, nil, id, visit)
+			}
+			w.walk(xDecl.Type, nil, id, visit)
+			if xDecl.Body != nil {
+				w.walk(xDecl.Body, nil, id, visit)
+			}
+
+		case *ast.GenDecl:
+			switch xDecl.Tok {
+			case token.VAR:
+				w.walk(xDecl, nil, w.pkg.PkgPath+".init", visit)
+			case token.TYPE, token.CONST:
+				w.walk(xDecl, nil, "", visit)
+			}
+		}
+	}
+}
+
+func (w *funcWalker) walk(node ast.Node, stack []ast.Node, fn string, visit func(ast.Node, []ast.Node, string)) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		visit(n, stack, fn)
+		if lit, ok := n.(*ast.FuncLit); ok && fn != "" {
+			w.closures[fn]++
+			closure := fmt.Sprintf("%s$%d", fn, w.closures[fn])
+			if w.enter != nil {
+				w.enter(lit, closure, fn)
+			}
+			stack := append(stack[:len(stack):len(stack)], lit)
+			w.walk(lit.Type, stack, closure, visit)
+			w.walk(lit.Body, stack, closure, visit)
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
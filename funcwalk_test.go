@@ -0,0 +1,38 @@
+package goretriever
+
+import "testing"
+
+func TestBuildCallGraphClosureInType(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"p/p.go": `package p
+
+import "unsafe"
+
+type T [unsafe.Sizeof(func() {})]byte
+
+const C = unsafe.Sizeof(func() { g() })
+
+func f() {
+	func() { g() }()
+}
+
+func g() {}
+`,
+	})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := graph.Node("$1"); n != nil {
+		t.Errorf("closure of a type declaration got node %q", n.ID)
+	}
+	closure := graph.Node("example.com/m/p.f$1")
+	if closure == nil {
+		t.Fatal("no node for the closure of f")
+	}
+	if len(closure.Out) != 1 || closure.Out[0].Callee.ID != "example.com/m/p.g" {
+		t.Errorf("closure of f calls %v, want example.com/m/p.g", closure.Out)
+	}
+}
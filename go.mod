@@ -5,12 +5,8 @@ go 1.23.0
 toolchain go1.23.1
 
 require (
+	golang.org/x/mod v0.24.0
 	golang.org/x/tools v0.31.0
-	golang.org/x/tools/go/pointer v0.1.0-deprecated
 )
 
-require (
-	golang.org/x/mod v0.24.0 // indirect
-	golang.org/x/sync v0.12.0 // indirect
-	golang.org/x/sys v0.31.0 // indirect
-)
+require golang.org/x/sync v0.12.0 // indirect
@@ -0,0 +1,464 @@
+package goretriever
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node labels and edge types of the graph database export.
+const (
+	GraphDBPackage   = "Package"
+	GraphDBType      = "Type"
+	GraphDBInterface = "Interface"
+	GraphDBFunction  = "Function"
+	GraphDBField     = "Field"
+
+	GraphDBContains   = "CONTAINS"
+	GraphDBCalls      = "CALLS"
+	GraphDBImplements = "IMPLEMENTS"
+	GraphDBEmbeds     = "EMBEDS"
+	GraphDBImports    = "IMPORTS"
+)
+
+type GraphDBNode struct {
+	ID       string
+	Label    string
+	Name     string
+	Package  string
+	Receiver string
+	Type     string
+	File     string
+	Line     int
+	External bool
+}
+
+type GraphDBEdge struct {
+	From string
+	To   string
+	Type string
+}
+
+// GraphDBExport holds the nodes and edges written by WriteCSV
+// and WriteCypher.
+type GraphDBExport struct {
+	Nodes []*GraphDBNode
+	Edges []*GraphDBEdge
+
+	nodes map[string]*GraphDBNode
+	edges map[GraphDBEdge]bool
+}
+
+func (e *GraphDBExport) addNode(n *GraphDBNode) *GraphDBNode {
+	if old, ok := e.nodes[n.ID]; ok {
+		return old
+	}
+	e.nodes[n.ID] = n
+	e.Nodes = append(e.Nodes, n)
+	return n
+}
+
+func (e *GraphDBExport) addEdge(from, to, typ string) {
+	edge := GraphDBEdge{From: from, To: to, Type: typ}
+	if e.edges[edge] {
+		return
+	}
+	e.edges[edge] = true
+	e.Edges = append(e.Edges, &edge)
+}
+
+func packageID(pkg *Package) string {
+	switch {
+	case pkg.Path != "":
+		return pkg.Path
+	case pkg.Dir != "":
+		return filepath.ToSlash(pkg.Dir)
+	}
+	return pkg.Name
+}
+
+// TypeRelation is an IMPLEMENTS or EMBEDS relation between two named
+// types, identified as in FindInterfaces by package path and name.
+type TypeRelation struct {
+	From      string
+	To        string
+	Type      string
+	Interface bool // To is an interface
+}
+
+// FindTypeRelations looks for the types implementing the interfaces of
+// the packages, and for the types embedded in their types.
+func FindTypeRelations(projectPath string, packagePattern []string, envs []string, opts ...Option) ([]*TypeRelation, error) {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return a.TypeRelations(), nil
+}
+
+// TypeRelations looks for the IMPLEMENTS and EMBEDS relations of the
+// types of the loaded packages.
+func (a *Analyzer) TypeRelations() []*TypeRelation {
+	var (
+		named     []*types.Named
+		relations []*TypeRelation
+	)
+
+	for _, pkg := range a.Packages {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			if t, ok := tn.Type().(*types.Named); ok {
+				named = append(named, t)
+			}
+		}
+	}
+
+	embeds := func(from *types.Named, typ types.Type) {
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		t, ok := typ.(*types.Named)
+		if !ok {
+			return
+		}
+		t = t.Origin()
+		relations = append(relations, &TypeRelation{
+			From:      typeRelationID(from),
+			To:        typeRelationID(t),
+			Type:      GraphDBEmbeds,
+			Interface: types.IsInterface(t),
+		})
+	}
+
+	var interfaces []*types.Named
+	for _, t := range named {
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			for i := 0; i < u.NumFields(); i++ {
+				if u.Field(i).Embedded() {
+					embeds(t, u.Field(i).Type())
+				}
+			}
+		case *types.Interface:
+			for i := 0; i < u.NumEmbeddeds(); i++ {
+				embeds(t, u.EmbeddedType(i))
+			}
+			if u.NumMethods() > 0 && t.TypeParams() == nil {
+				interfaces = append(interfaces, t)
+			}
+		}
+	}
+
+	for _, t := range named {
+		if types.IsInterface(t) || t.TypeParams() != nil {
+			continue
+		}
+		for _, iface := range interfaces {
+			i := iface.Underlying().(*types.Interface)
+			if types.Implements(t, i) || types.Implements(types.NewPointer(t), i) {
+				relations = append(relations, &TypeRelation{
+					From:      typeRelationID(t),
+					To:        typeRelationID(iface),
+					Type:      GraphDBImplements,
+					Interface: true,
+				})
+			}
+		}
+	}
+	return relations
+}
+
+func typeRelationID(t *types.Named) string {
+	obj := t.Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// NewGraphDBExport builds the nodes and edges of the packages and of the
+// call graph. Types listed in interfaces, as returned by
+// FindInterfaces, are exported as interfaces. The IMPLEMENTS and EMBEDS
+// edges come from relations, as returned by FindTypeRelations.
+func NewGraphDBExport(pkgs []*Package, callGraph *CallGraph, interfaces map[string]bool, relations []*TypeRelation) *GraphDBExport {
+	e := &GraphDBExport{
+		nodes: make(map[string]*GraphDBNode),
+		edges: make(map[GraphDBEdge]bool),
+	}
+
+	type typeInfo struct {
+		id  string
+		pkg *Package
+		s   *Struct
+	}
+	var types []*typeInfo
+
+	for _, pkg := range pkgs {
+		pkgID := packageID(pkg)
+		e.addNode(&GraphDBNode{
+			ID:      pkgID,
+			Label:   GraphDBPackage,
+			Name:    pkg.Name,
+			Package: pkgID,
+		})
+	}
+
+	for _, pkg := range pkgs {
+		pkgID := packageID(pkg)
+
+		for _, sym := range pkg.Symbols() {
+			if sym.Kind != SymbolType {
+				continue
+			}
+			s := pkg.Structs[sym.Name]
+			typeID := pkgID + "." + s.Name
+
+			label := GraphDBType
+			if s.Interface || interfaces[typeID] {
+				label = GraphDBInterface
+			}
+			e.addNode(&GraphDBNode{
+				ID:      typeID,
+				Label:   label,
+				Name:    s.Name,
+				Package: pkgID,
+				File:    s.File,
+				Line:    s.Line,
+			})
+			e.addEdge(pkgID, typeID, GraphDBContains)
+
+			types = append(types, &typeInfo{
+				id:  typeID,
+				pkg: pkg,
+				s:   s,
+			})
+		}
+	}
+
+	for _, info := range types {
+		var (
+			pkg    = info.pkg
+			pkgID  = packageID(pkg)
+			s      = info.s
+			typeID = info.id
+		)
+
+		for _, field := range s.Fields {
+			if field.Embedded {
+				continue
+			}
+
+			if s.Interface {
+				e.addNode(&GraphDBNode{
+					ID:       typeID + "." + field.Name,
+					Label:    GraphDBFunction,
+					Name:     field.Name,
+					Package:  pkgID,
+					Receiver: s.Name,
+					Type:     field.Type,
+				})
+				e.addEdge(typeID, typeID+"."+field.Name, GraphDBContains)
+				continue
+			}
+
+			e.addNode(&GraphDBNode{
+				ID:       typeID + "." + field.Name,
+				Label:    GraphDBField,
+				Name:     field.Name,
+				Package:  pkgID,
+				Receiver: s.Name,
+				Type:     field.Type,
+			})
+			e.addEdge(typeID, typeID+"."+field.Name, GraphDBContains)
+		}
+
+		names := make([]string, 0, len(s.Methods))
+		for name := range s.Methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			m := s.Methods[name]
+			e.addNode(&GraphDBNode{
+				ID:       typeID + "." + name,
+				Label:    GraphDBFunction,
+				Name:     name,
+				Package:  pkgID,
+				Receiver: s.Name,
+				File:     m.File,
+				Line:     m.Line,
+			})
+			e.addEdge(typeID, typeID+"."+name, GraphDBContains)
+		}
+	}
+
+	for _, pkg := range pkgs {
+		pkgID := packageID(pkg)
+
+		for _, imp := range pkg.Imports {
+			e.addNode(&GraphDBNode{
+				ID:       imp,
+				Label:    GraphDBPackage,
+				Name:     path.Base(imp),
+				Package:  imp,
+				External: true,
+			})
+			e.addEdge(pkgID, imp, GraphDBImports)
+		}
+
+		for _, sym := range pkg.Symbols() {
+			if sym.Kind != SymbolFunction {
+				continue
+			}
+			e.addNode(&GraphDBNode{
+				ID:      pkgID + "." + sym.Name,
+				Label:   GraphDBFunction,
+				Name:    sym.Name,
+				Package: pkgID,
+				File:    sym.File,
+				Line:    sym.Line,
+			})
+			e.addEdge(pkgID, pkgID+"."+sym.Name, GraphDBContains)
+		}
+	}
+
+	// The types of other packages are added as external nodes.
+	addType := func(id string, iface bool) {
+		label := GraphDBType
+		if iface {
+			label = GraphDBInterface
+		}
+		name, pkgID := id, ""
+		if i := strings.LastIndex(id, "."); i >= 0 {
+			name, pkgID = id[i+1:], id[:i]
+		}
+		e.addNode(&GraphDBNode{
+			ID:       id,
+			Label:    label,
+			Name:     name,
+			Package:  pkgID,
+			External: true,
+		})
+	}
+	for _, r := range relations {
+		addType(r.From, false)
+		addType(r.To, r.Interface)
+		e.addEdge(r.From, r.To, r.Type)
+	}
+
+	// Call graph nodes are matched to the model by their qualified name.
+	addFunction := func(n *CallNode) string {
+		id := n.QualifiedName()
+		e.addNode(&GraphDBNode{
+			ID:       id,
+			Label:    GraphDBFunction,
+			Name:     n.Name,
+			Package:  n.Pkg,
+			Receiver: n.Recv,
+			Type:     n.Signature,
+			External: true,
+		})
+		return id
+	}
+	if callGraph != nil {
+		for _, edge := range callGraph.Edges() {
+			e.addEdge(addFunction(edge.Caller), addFunction(edge.Callee), GraphDBCalls)
+		}
+	}
+
+	return e
+}
+
+// WriteCSV writes nodes.csv and relationships.csv into dir, in the
+// format of the neo4j-admin bulk importer.
+func (e *GraphDBExport) WriteCSV(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	writeFile := func(name string, records [][]string) error {
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+
+		w := csv.NewWriter(file)
+		if err := w.WriteAll(records); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	nodes := [][]string{{
+		"id:ID", "name", "package", "receiver", "type", "file", "line:int", "external:boolean", ":LABEL",
+	}}
+	for _, n := range e.Nodes {
+		nodes = append(nodes, []string{
+			n.ID, n.Name, n.Package, n.Receiver, n.Type, n.File,
+			strconv.Itoa(n.Line), strconv.FormatBool(n.External), n.Label,
+		})
+	}
+	if err := writeFile("nodes.csv", nodes); err != nil {
+		return err
+	}
+
+	edges := [][]string{{":START_ID", ":END_ID", ":TYPE"}}
+	for _, edge := range e.Edges {
+		edges = append(edges, []string{edge.From, edge.To, edge.Type})
+	}
+	return writeFile("relationships.csv", edges)
+}
+
+func cypherQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// WriteCypher writes a Cypher script creating the nodes and edges,
+// nodes are merged by id so the script can be run repeatedly.
+func (e *GraphDBExport) WriteCypher(w io.Writer) error {
+	b := &strings.Builder{}
+
+	for _, label := range []string{GraphDBPackage, GraphDBType, GraphDBInterface, GraphDBFunction, GraphDBField} {
+		fmt.Fprintf(b, "CREATE CONSTRAINT IF NOT EXISTS FOR (n:%s) REQUIRE n.id IS UNIQUE;\n", label)
+	}
+
+	for _, n := range e.Nodes {
+		fmt.Fprintf(b, "MERGE (n:%s {id: %s}) SET n.name = %s, n.package = %s",
+			n.Label, cypherQuote(n.ID), cypherQuote(n.Name), cypherQuote(n.Package))
+		if n.Receiver != "" {
+			fmt.Fprintf(b, ", n.receiver = %s", cypherQuote(n.Receiver))
+		}
+		if n.Type != "" {
+			fmt.Fprintf(b, ", n.type = %s", cypherQuote(n.Type))
+		}
+		if n.File != "" {
+			fmt.Fprintf(b, ", n.file = %s, n.line = %d", cypherQuote(n.File), n.Line)
+		}
+		fmt.Fprintf(b, ", n.external = %t;\n", n.External)
+	}
+
+	for _, edge := range e.Edges {
+		from, to := e.nodes[edge.From], e.nodes[edge.To]
+		fmt.Fprintf(b, "MATCH (a:%s {id: %s}), (b:%s {id: %s}) MERGE (a)-[:%s]->(b);\n",
+			from.Label, cypherQuote(from.ID), to.Label, cypherQuote(to.ID), edge.Type)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,149 @@
+package goretriever
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const graphDBLib = `package lib
+
+type Closer interface {
+	Close() error
+}
+
+func Open(name string) int { return len(name) }
+`
+
+const graphDBStore = `package store
+
+import "example.com/m/lib"
+
+type Base struct{ id int }
+
+func (b *Base) Close() error { return nil }
+
+// File is stored on disk.
+type File struct {
+	Base
+	Name string
+}
+
+func New(name string) *File {
+	lib.Open(name)
+	return &File{Name: name}
+}
+`
+
+func newTestGraphDBExport(t *testing.T) *GraphDBExport {
+	t.Helper()
+
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go":     graphDBLib,
+		"store/store.go": graphDBStore,
+	})
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGraphDBExport(Parse(dir), a.BuildCallGraph(), a.Interfaces(), a.TypeRelations())
+}
+
+func TestGraphDBExport(t *testing.T) {
+	e := newTestGraphDBExport(t)
+
+	labels := make(map[string]string)
+	for _, n := range e.Nodes {
+		labels[n.ID] = n.Label
+	}
+	for id, label := range map[string]string{
+		"example.com/m/store":            GraphDBPackage,
+		"example.com/m/store.File":       GraphDBType,
+		"example.com/m/store.File.Name":  GraphDBField,
+		"example.com/m/store.Base.Close": GraphDBFunction,
+		"example.com/m/store.New":        GraphDBFunction,
+		"example.com/m/lib.Closer":       GraphDBInterface,
+	} {
+		if labels[id] != label {
+			t.Errorf("node %s has label %q, want %q", id, labels[id], label)
+		}
+	}
+
+	edges := make(map[GraphDBEdge]bool)
+	for _, edge := range e.Edges {
+		edges[*edge] = true
+	}
+	for _, edge := range []GraphDBEdge{
+		{"example.com/m/store", "example.com/m/store.File", GraphDBContains},
+		{"example.com/m/store.File", "example.com/m/store.File.Name", GraphDBContains},
+		{"example.com/m/store", "example.com/m/lib", GraphDBImports},
+		{"example.com/m/store.File", "example.com/m/store.Base", GraphDBEmbeds},
+		{"example.com/m/store.Base", "example.com/m/lib.Closer", GraphDBImplements},
+		{"example.com/m/store.File", "example.com/m/lib.Closer", GraphDBImplements},
+		{"example.com/m/store.New", "example.com/m/lib.Open", GraphDBCalls},
+	} {
+		if !edges[edge] {
+			t.Errorf("edge %s -[%s]-> %s is missing", edge.From, edge.Type, edge.To)
+		}
+	}
+	if edges[GraphDBEdge{"example.com/m/store.File", "example.com/m/store.Base", GraphDBImplements}] {
+		t.Error("File implements the struct Base")
+	}
+}
+
+func TestGraphDBWriteCSV(t *testing.T) {
+	e := newTestGraphDBExport(t)
+
+	dir := t.TempDir()
+	if err := e.WriteCSV(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) [][]string {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	nodes := read("nodes.csv")
+	if len(nodes) != len(e.Nodes)+1 || nodes[0][0] != "id:ID" || nodes[0][len(nodes[0])-1] != ":LABEL" {
+		t.Errorf("nodes.csv has %d records, header %v", len(nodes), nodes[0])
+	}
+	edges := read("relationships.csv")
+	if len(edges) != len(e.Edges)+1 || strings.Join(edges[0], ",") != ":START_ID,:END_ID,:TYPE" {
+		t.Errorf("relationships.csv has %d records, header %v", len(edges), edges[0])
+	}
+}
+
+func TestGraphDBWriteCypher(t *testing.T) {
+	e := newTestGraphDBExport(t)
+
+	var b bytes.Buffer
+	if err := e.WriteCypher(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"CREATE CONSTRAINT IF NOT EXISTS FOR (n:Interface) REQUIRE n.id IS UNIQUE;\n",
+		"MERGE (n:Type {id: 'example.com/m/store.File'}) SET n.name = 'File'",
+		"MATCH (a:Type {id: 'example.com/m/store.File'}), (b:Interface {id: 'example.com/m/lib.Closer'}) MERGE (a)-[:IMPLEMENTS]->(b);\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cypher script lacks %q", want)
+		}
+	}
+	if got := cypherQuote(`it's \ here`); got != `'it\'s \\ here'` {
+		t.Errorf("cypherQuote = %s", got)
+	}
+}
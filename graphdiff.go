@@ -0,0 +1,264 @@
+package goretriever
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CalleeChange is a function of both graphs whose callees changed.
+type CalleeChange struct {
+	// Node is the function in the new graph.
+	Node    *CallNode
+	Added   []*CallNode
+	Removed []*CallNode
+}
+
+// CallGraphDiff is the difference between two call graphs. Functions
+// and edges are matched by id, added ones come from the new graph and
+// removed ones from the old graph.
+type CallGraphDiff struct {
+	Old *CallGraph
+	New *CallGraph
+
+	// all lists are ordered by id
+	AddedFuncs   []*CallNode
+	RemovedFuncs []*CallNode
+	AddedEdges   []*CallEdge
+	RemovedEdges []*CallEdge
+	Changed      []*CalleeChange
+}
+
+// edgeIDs returns the edges of g by caller and callee id.
+func edgeIDs(g *CallGraph) map[[2]string]*CallEdge {
+	edges := make(map[[2]string]*CallEdge)
+	for _, e := range g.Edges() {
+		edges[[2]string{e.Caller.ID, e.Callee.ID}] = e
+	}
+	return edges
+}
+
+// DiffCallGraphs compares the call graphs of two versions of the same
+// packages.
+func DiffCallGraphs(oldGraph, newGraph *CallGraph) *CallGraphDiff {
+	d := &CallGraphDiff{Old: oldGraph, New: newGraph}
+
+	for _, n := range newGraph.Nodes() {
+		if oldGraph.Node(n.ID) == nil {
+			d.AddedFuncs = append(d.AddedFuncs, n)
+		}
+	}
+	for _, n := range oldGraph.Nodes() {
+		if newGraph.Node(n.ID) == nil {
+			d.RemovedFuncs = append(d.RemovedFuncs, n)
+		}
+	}
+
+	oldEdges, newEdges := edgeIDs(oldGraph), edgeIDs(newGraph)
+	changed := make(map[string]*CalleeChange)
+	change := func(id string) *CalleeChange {
+		c, ok := changed[id]
+		if !ok {
+			c = &CalleeChange{Node: newGraph.Node(id)}
+			changed[id] = c
+		}
+		return c
+	}
+
+	for key, e := range newEdges {
+		if _, ok := oldEdges[key]; ok {
+			continue
+		}
+		d.AddedEdges = append(d.AddedEdges, e)
+		if oldGraph.Node(key[0]) != nil {
+			c := change(key[0])
+			c.Added = append(c.Added, e.Callee)
+		}
+	}
+	for key, e := range oldEdges {
+		if _, ok := newEdges[key]; ok {
+			continue
+		}
+		d.RemovedEdges = append(d.RemovedEdges, e)
+		if newGraph.Node(key[0]) != nil {
+			c := change(key[0])
+			c.Removed = append(c.Removed, e.Callee)
+		}
+	}
+
+	for _, c := range changed {
+		sort.Slice(c.Added, func(i, j int) bool { return c.Added[i].ID < c.Added[j].ID })
+		sort.Slice(c.Removed, func(i, j int) bool { return c.Removed[i].ID < c.Removed[j].ID })
+		d.Changed = append(d.Changed, c)
+	}
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Node.ID < d.Changed[j].Node.ID })
+	sortEdges(d.AddedEdges)
+	sortEdges(d.RemovedEdges)
+	return d
+}
+
+func sortEdges(edges []*CallEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Caller.ID != edges[j].Caller.ID {
+			return edges[i].Caller.ID < edges[j].Caller.ID
+		}
+		return edges[i].Callee.ID < edges[j].Callee.ID
+	})
+}
+
+// NewlyReachable returns the functions of the new graph reachable from
+// roots which were not reachable from them in the old graph. Via and
+// New.ShortestPath tell how they are reached, eg. with the roots of
+//
+//	RootIDs(InferRootFunctionsFromGraph(d.New), RootExported)
+//
+// they are the functions a change makes reachable from the public API.
+func (d *CallGraphDiff) NewlyReachable(roots []string, filter *QueryFilter) []*Reached {
+	return reachableDiff(d.New, d.Old, roots, filter)
+}
+
+// NoLongerReachable returns the functions of the old graph reachable
+// from roots which are not reachable from them in the new graph.
+func (d *CallGraphDiff) NoLongerReachable(roots []string, filter *QueryFilter) []*Reached {
+	return reachableDiff(d.Old, d.New, roots, filter)
+}
+
+func reachableDiff(g, other *CallGraph, roots []string, filter *QueryFilter) []*Reached {
+	before := make(map[string]bool)
+	for _, r := range other.Reachable(roots, filter) {
+		before[r.Node.ID] = true
+	}
+
+	var reached []*Reached
+	for _, r := range g.Reachable(roots, filter) {
+		if !before[r.Node.ID] {
+			reached = append(reached, r)
+		}
+	}
+	return reached
+}
+
+// RootIDs returns the ids of the roots having one of reasons, or of
+// all roots without reasons.
+func RootIDs(roots []*Root, reasons ...RootReason) []string {
+	var ids []string
+	for _, r := range roots {
+		match := len(reasons) == 0
+		for _, reason := range reasons {
+			match = match || r.Has(reason)
+		}
+		if match {
+			ids = append(ids, r.Node.ID)
+		}
+	}
+	return ids
+}
+
+// String renders the diff with one line per change: + and - for added
+// and removed functions and edges, ~ for the functions whose callees
+// changed.
+func (d *CallGraphDiff) String() string {
+	b := &strings.Builder{}
+	for _, n := range d.AddedFuncs {
+		fmt.Fprintf(b, "+ %s\n", n.ID)
+	}
+	for _, n := range d.RemovedFuncs {
+		fmt.Fprintf(b, "- %s\n", n.ID)
+	}
+	for _, e := range d.AddedEdges {
+		fmt.Fprintf(b, "+ %s -> %s\n", e.Caller.ID, e.Callee.ID)
+	}
+	for _, e := range d.RemovedEdges {
+		fmt.Fprintf(b, "- %s -> %s\n", e.Caller.ID, e.Callee.ID)
+	}
+	for _, c := range d.Changed {
+		var callees []string
+		for _, n := range c.Added {
+			callees = append(callees, "+"+n.ID)
+		}
+		for _, n := range c.Removed {
+			callees = append(callees, "-"+n.ID)
+		}
+		fmt.Fprintf(b, "~ %s: %s\n", c.Node.ID, strings.Join(callees, ", "))
+	}
+	return b.String()
+}
+
+// DiffTrees builds the call graphs of the packages matching
+// packagePattern in two copies of a project and compares them.
+func DiffTrees(oldPath, newPath string, packagePattern []string, envs []string, opts ...Option) (*CallGraphDiff, error) {
+	oldGraph, err := BuildCallGraph(oldPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newGraph, err := BuildCallGraph(newPath, packagePattern, envs, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", newPath, err)
+	}
+	return DiffCallGraphs(oldGraph, newGraph), nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// DiffRevisions compares the call graphs of projectPath at two git
+// revisions. Both revisions are checked out in temporary worktrees,
+// projectPath itself is left untouched.
+func DiffRevisions(projectPath, oldRev, newRev string, packagePattern []string, envs []string, opts ...Option) (*CallGraphDiff, error) {
+	abs, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	top, err := git(abs, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	// the project may be a sub directory of the repository
+	top, err = filepath.EvalSymlinks(top)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	var graphs [2]*CallGraph
+	for i, rev := range []string{oldRev, newRev} {
+		dir, err := os.MkdirTemp("", "goretriever-")
+		if err != nil {
+			return nil, err
+		}
+		graphs[i], err = revisionCallGraph(top, dir, rev, rel, packagePattern, envs, opts)
+		os.RemoveAll(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rev, err)
+		}
+	}
+	return DiffCallGraphs(graphs[0], graphs[1]), nil
+}
+
+func revisionCallGraph(repo, dir, rev, rel string, packagePattern []string, envs []string, opts []Option) (*CallGraph, error) {
+	if _, err := git(repo, "worktree", "add", "--detach", dir, rev); err != nil {
+		return nil, err
+	}
+	defer git(repo, "worktree", "remove", "--force", dir)
+
+	return BuildCallGraph(filepath.Join(dir, rel), packagePattern, envs, opts...)
+}
@@ -0,0 +1,128 @@
+package goretriever
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	billingV1 = `package billing
+
+func Pay() { validate() }
+
+func validate() {}
+
+func charge() { audit() }
+
+func audit() {}
+
+func legacy() { validate() }
+`
+	billingV2 = `package billing
+
+func Pay() {
+	validate()
+	charge()
+}
+
+func validate() {}
+
+func charge() { audit() }
+
+func audit() {}
+
+func refund() {}
+`
+)
+
+func checkBillingDiff(t *testing.T, d *CallGraphDiff, pkg string) {
+	t.Helper()
+
+	want := strings.ReplaceAll(`+ P.refund
+- P.legacy
++ P.Pay -> P.charge
+- P.legacy -> P.validate
+~ P.Pay: +P.charge
+`, "P.", pkg+".")
+	if got := d.String(); got != want {
+		t.Errorf("diff is\n%s\nwant\n%s", got, want)
+	}
+
+	// charge was dead code, the change makes it reachable from Pay
+	roots := RootIDs(InferRootFunctionsFromGraph(d.New), RootExported)
+	if len(roots) != 1 || roots[0] != pkg+".Pay" {
+		t.Fatalf("exported roots are %v", roots)
+	}
+	var reached []string
+	for _, r := range d.NewlyReachable(roots, nil) {
+		reached = append(reached, strings.TrimPrefix(r.Node.ID, pkg+"."))
+	}
+	if strings.Join(reached, " ") != "charge audit" {
+		t.Errorf("newly reachable functions are %v", reached)
+	}
+	if lost := d.NoLongerReachable(roots, nil); len(lost) != 0 {
+		t.Errorf("no longer reachable functions are %v", lost)
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	oldDir := writeModule(t, map[string]string{"billing/billing.go": billingV1})
+	newDir := writeModule(t, map[string]string{"billing/billing.go": billingV2})
+
+	d, err := DiffTrees(oldDir, newDir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBillingDiff(t, d, "example.com/m/billing")
+
+	if len(d.Changed) != 1 || d.Changed[0].Node != d.New.Node("example.com/m/billing.Pay") {
+		t.Errorf("changed functions are %v", d.Changed)
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	// the project is a module in a sub directory of the repository
+	repo := writeModule(t, map[string]string{
+		"svc/go.mod":             "module example.com/svc\n\ngo 1.22\n",
+		"svc/billing/billing.go": billingV1,
+	})
+	commit := func(message string) {
+		t.Helper()
+		for _, args := range [][]string{
+			{"add", "-A"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", message},
+		} {
+			if _, err := git(repo, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := git(repo, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	commit("v1")
+	if err := os.WriteFile(filepath.Join(repo, "svc", "billing", "billing.go"), []byte(billingV2), 0666); err != nil {
+		t.Fatal(err)
+	}
+	commit("v2")
+
+	d, err := DiffRevisions(filepath.Join(repo, "svc"), "HEAD~1", "HEAD", []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBillingDiff(t, d, "example.com/svc/billing")
+
+	worktrees, err := git(repo, "worktree", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Split(worktrees, "\n")); n != 1 {
+		t.Errorf("%d worktrees left:\n%s", n, worktrees)
+	}
+
+	if _, err := DiffRevisions(filepath.Join(repo, "svc"), "missing", "HEAD", []string{"./..."}, nil); err == nil {
+		t.Error("no error for a missing revision")
+	}
+}
@@ -0,0 +1,361 @@
+package goretriever
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GraphFormat selects the output format of the call graph exporter.
+type GraphFormat int
+
+const (
+	GraphDOT GraphFormat = iota
+	GraphMermaid
+	GraphML
+	GraphJSON
+)
+
+// GraphExportOptions controls which part of a call graph is exported
+// and how it is laid out.
+type GraphExportOptions struct {
+	// Roots restricts the export to the nodes reachable from the
+	// functions with the given ids.
+	Roots []string
+	// Depth limits how far from Roots the export goes, 0 means no limit.
+	Depth int
+	// ClusterByPackage groups the nodes of every package together.
+	ClusterByPackage bool
+	// CollapseExternal replaces all functions of an external package
+	// with a single node named after the package.
+	CollapseExternal bool
+	// Internal lists the package path prefixes which are not external.
+	// When empty, the packages declaring functions are internal.
+	Internal []string
+}
+
+type exportNode struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Package  string `json:"package"`
+	External bool   `json:"external"`
+}
+
+type exportEdge struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Dynamic bool   `json:"dynamic,omitempty"`
+}
+
+type exportGraph struct {
+	Nodes []*exportNode `json:"nodes"`
+	Edges []*exportEdge `json:"edges"`
+}
+
+// nodeLabel names a node without its package path.
+func nodeLabel(n *CallNode) string {
+	if n.Recv != "" {
+		return n.Recv + "." + n.Name
+	}
+	return n.Name
+}
+
+func newExportGraph(callGraph *CallGraph, opts *GraphExportOptions) *exportGraph {
+	// adjacency tells for every callee whether the call is dynamic
+	adjacency := make(map[string]map[string]bool)
+	for _, e := range callGraph.Edges() {
+		if adjacency[e.Caller.ID] == nil {
+			adjacency[e.Caller.ID] = make(map[string]bool)
+		}
+		adjacency[e.Caller.ID][e.Callee.ID] = e.Dynamic
+	}
+
+	keep := make(map[string]bool)
+	if len(opts.Roots) == 0 {
+		for _, n := range callGraph.Nodes() {
+			keep[n.ID] = true
+		}
+	} else {
+		queue := append([]string(nil), opts.Roots...)
+		for _, root := range opts.Roots {
+			if callGraph.Node(root) != nil {
+				keep[root] = true
+			}
+		}
+		for depth := 1; len(queue) > 0 && (opts.Depth <= 0 || depth <= opts.Depth); depth++ {
+			var next []string
+			for _, id := range queue {
+				for callee := range adjacency[id] {
+					if !keep[callee] {
+						keep[callee] = true
+						next = append(next, callee)
+					}
+				}
+			}
+			queue = next
+		}
+	}
+
+	internal := opts.Internal
+	if len(internal) == 0 {
+		seen := make(map[string]bool)
+		for _, n := range callGraph.Nodes() {
+			if n.Pos.IsValid() && !seen[n.Pkg] {
+				seen[n.Pkg] = true
+				internal = append(internal, n.Pkg)
+			}
+		}
+	}
+	isExternal := func(pkg string) bool {
+		for _, prefix := range internal {
+			if pkg == prefix || strings.HasPrefix(pkg, strings.TrimSuffix(prefix, "/")+"/") {
+				return false
+			}
+		}
+		return true
+	}
+
+	var (
+		g      = &exportGraph{}
+		nodes  = make(map[string]*exportNode)
+		rename = make(map[string]string)
+	)
+	ids := make([]string, 0, len(keep))
+	for id := range keep {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		node := callGraph.Node(id)
+		n := &exportNode{
+			ID:      id,
+			Label:   nodeLabel(node),
+			Package: node.Pkg,
+		}
+		n.External = isExternal(n.Package)
+		if n.External && opts.CollapseExternal {
+			n.ID = n.Package
+			n.Label = n.Package
+		}
+		rename[id] = n.ID
+		if nodes[n.ID] == nil {
+			nodes[n.ID] = n
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+
+	edges := make(map[[2]string]*exportEdge)
+	for _, caller := range ids {
+		for callee, dynamic := range adjacency[caller] {
+			if !keep[callee] {
+				continue
+			}
+			key := [2]string{rename[caller], rename[callee]}
+			if key[0] == key[1] && key[0] != caller {
+				// collapsed package calling itself
+				continue
+			}
+			if e, ok := edges[key]; ok {
+				// merged edges are dynamic only if all of them are
+				e.Dynamic = e.Dynamic && dynamic
+				continue
+			}
+			e := &exportEdge{Source: key[0], Target: key[1], Dynamic: dynamic}
+			edges[key] = e
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+
+	return g
+}
+
+// packages returns the nodes grouped by package, in package order.
+func (g *exportGraph) packages() ([]string, map[string][]*exportNode) {
+	var names []string
+	groups := make(map[string][]*exportNode)
+	for _, n := range g.Nodes {
+		if groups[n.Package] == nil {
+			names = append(names, n.Package)
+		}
+		groups[n.Package] = append(groups[n.Package], n)
+	}
+	sort.Strings(names)
+	return names, groups
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (g *exportGraph) writeDOT(b *strings.Builder, opts *GraphExportOptions) {
+	b.WriteString("digraph callgraph {\n\tnode [shape=box];\n")
+
+	writeNode := func(indent string, n *exportNode) {
+		fmt.Fprintf(b, "%s%s [label=%s", indent, dotQuote(n.ID), dotQuote(n.Label))
+		if n.External {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+
+	if opts.ClusterByPackage {
+		names, groups := g.packages()
+		for i, pkg := range names {
+			fmt.Fprintf(b, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, dotQuote(pkg))
+			for _, n := range groups[pkg] {
+				writeNode("\t\t", n)
+			}
+			b.WriteString("\t}\n")
+		}
+	} else {
+		for _, n := range g.Nodes {
+			writeNode("\t", n)
+		}
+	}
+
+	for _, e := range g.Edges {
+		if e.Dynamic {
+			fmt.Fprintf(b, "\t%s -> %s [style=dashed];\n", dotQuote(e.Source), dotQuote(e.Target))
+			continue
+		}
+		fmt.Fprintf(b, "\t%s -> %s;\n", dotQuote(e.Source), dotQuote(e.Target))
+	}
+	b.WriteString("}\n")
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s) + `"`
+}
+
+func (g *exportGraph) writeMermaid(b *strings.Builder, opts *GraphExportOptions) {
+	b.WriteString("flowchart LR\n")
+
+	// mermaid ids must be plain identifiers
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	writeNode := func(indent string, n *exportNode) {
+		fmt.Fprintf(b, "%s%s[%s]\n", indent, ids[n.ID], mermaidQuote(n.Label))
+	}
+
+	if opts.ClusterByPackage {
+		names, groups := g.packages()
+		for i, pkg := range names {
+			fmt.Fprintf(b, "\tsubgraph p%d[%s]\n", i, mermaidQuote(pkg))
+			for _, n := range groups[pkg] {
+				writeNode("\t\t", n)
+			}
+			b.WriteString("\tend\n")
+		}
+	} else {
+		for _, n := range g.Nodes {
+			writeNode("\t", n)
+		}
+	}
+
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Dynamic {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "\t%s %s %s\n", ids[e.Source], arrow, ids[e.Target])
+	}
+
+	var external []string
+	for _, n := range g.Nodes {
+		if n.External {
+			external = append(external, ids[n.ID])
+		}
+	}
+	if len(external) > 0 {
+		b.WriteString("\tclassDef external stroke-dasharray: 5 5\n")
+		fmt.Fprintf(b, "\tclass %s external\n", strings.Join(external, ","))
+	}
+}
+
+func xmlEscape(s string) string {
+	b := &strings.Builder{}
+	xml.EscapeText(b, []byte(s))
+	return b.String()
+}
+
+func (g *exportGraph) writeGraphML(b *strings.Builder, opts *GraphExportOptions) {
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="package" for="node" attr.name="package" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="external" for="node" attr.name="external" attr.type="boolean"/>` + "\n")
+	b.WriteString(`  <key id="dynamic" for="edge" attr.name="dynamic" attr.type="boolean"/>` + "\n")
+	b.WriteString(`  <graph id="callgraph" edgedefault="directed">` + "\n")
+
+	writeNode := func(indent string, n *exportNode) {
+		fmt.Fprintf(b, "%s<node id=\"%s\">\n", indent, xmlEscape(n.ID))
+		fmt.Fprintf(b, "%s  <data key=\"label\">%s</data>\n", indent, xmlEscape(n.Label))
+		fmt.Fprintf(b, "%s  <data key=\"package\">%s</data>\n", indent, xmlEscape(n.Package))
+		fmt.Fprintf(b, "%s  <data key=\"external\">%t</data>\n", indent, n.External)
+		fmt.Fprintf(b, "%s</node>\n", indent)
+	}
+
+	if opts.ClusterByPackage {
+		names, groups := g.packages()
+		for i, pkg := range names {
+			fmt.Fprintf(b, "    <node id=\"package%d\">\n", i)
+			fmt.Fprintf(b, "      <data key=\"label\">%s</data>\n", xmlEscape(pkg))
+			fmt.Fprintf(b, "      <graph id=\"package%d:\" edgedefault=\"directed\">\n", i)
+			for _, n := range groups[pkg] {
+				writeNode("        ", n)
+			}
+			b.WriteString("      </graph>\n    </node>\n")
+		}
+	} else {
+		for _, n := range g.Nodes {
+			writeNode("    ", n)
+		}
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "    <edge source=\"%s\" target=\"%s\">\n", xmlEscape(e.Source), xmlEscape(e.Target))
+		fmt.Fprintf(b, "      <data key=\"dynamic\">%t</data>\n    </edge>\n", e.Dynamic)
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+}
+
+// ExportCallGraph writes callGraph, or the part of it selected by
+// opts, in the given format.
+func ExportCallGraph(w io.Writer, callGraph *CallGraph, format GraphFormat, opts *GraphExportOptions) error {
+	if opts == nil {
+		opts = &GraphExportOptions{}
+	}
+
+	g := newExportGraph(callGraph, opts)
+	b := &strings.Builder{}
+
+	switch format {
+	case GraphDOT:
+		g.writeDOT(b, opts)
+	case GraphMermaid:
+		g.writeMermaid(b, opts)
+	case GraphML:
+		g.writeGraphML(b, opts)
+	case GraphJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(g)
+	default:
+		return fmt.Errorf("unknown graph format %d", format)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,148 @@
+package goretriever
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// exportTestGraph builds main -> run -> lib.Load, lib.Save and a
+// dynamic call from run to (*T).Close.
+func exportTestGraph() *CallGraph {
+	g := NewCallGraph()
+	node := func(id, pkg, recv, name string) *CallNode {
+		n := g.AddNode(id)
+		n.Pkg, n.Recv, n.Name = pkg, recv, name
+		return n
+	}
+	var (
+		main   = node("example.com/app.main", "example.com/app", "", "main")
+		run    = node("example.com/app.run", "example.com/app", "", "run")
+		closer = node("(*example.com/app.T).Close", "example.com/app", "T", "Close")
+		load   = node("example.com/lib.Load", "example.com/lib", "", "Load")
+		save   = node("example.com/lib.Save", "example.com/lib", "", "Save")
+	)
+	g.AddEdge(main, run)
+	g.AddDynamicEdge(run, closer)
+	g.AddEdge(run, load)
+	g.AddEdge(run, save)
+	g.AddEdge(load, save)
+	return g
+}
+
+func TestExportCallGraphJSON(t *testing.T) {
+	var b bytes.Buffer
+	err := ExportCallGraph(&b, exportTestGraph(), GraphJSON, &GraphExportOptions{
+		Internal:         []string{"example.com/app"},
+		CollapseExternal: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g exportGraph
+	if err := json.Unmarshal(b.Bytes(), &g); err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n.ID)
+	}
+	want := "(*example.com/app.T).Close example.com/app.main example.com/app.run example.com/lib"
+	if got := strings.Join(nodes, " "); got != want {
+		t.Errorf("nodes are %s, want %s", got, want)
+	}
+
+	var edges []string
+	for _, e := range g.Edges {
+		edge := e.Source + "->" + e.Target
+		if e.Dynamic {
+			edge += "(dynamic)"
+		}
+		edges = append(edges, edge)
+	}
+	// lib.Load -> lib.Save is inside the collapsed package
+	want = "example.com/app.main->example.com/app.run " +
+		"example.com/app.run->(*example.com/app.T).Close(dynamic) " +
+		"example.com/app.run->example.com/lib"
+	if got := strings.Join(edges, " "); got != want {
+		t.Errorf("edges are %s, want %s", got, want)
+	}
+}
+
+func TestExportCallGraphDepth(t *testing.T) {
+	var b bytes.Buffer
+	err := ExportCallGraph(&b, exportTestGraph(), GraphDOT, &GraphExportOptions{
+		Roots: []string{"example.com/app.main"},
+		Depth: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	if !strings.Contains(out, `"example.com/app.main" -> "example.com/app.run";`) {
+		t.Errorf("edge from main is missing\n%s", out)
+	}
+	if strings.Contains(out, "lib.Load") {
+		t.Errorf("lib.Load is deeper than 1\n%s", out)
+	}
+}
+
+func TestExportCallGraphMermaid(t *testing.T) {
+	var b bytes.Buffer
+	err := ExportCallGraph(&b, exportTestGraph(), GraphMermaid, &GraphExportOptions{
+		Internal:         []string{"example.com/app"},
+		ClusterByPackage: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"flowchart LR\n",
+		"\tsubgraph p1[\"example.com/lib\"]\n",
+		"\t\tn0[\"T.Close\"]\n",
+		"\tn2 -.-> n0\n",
+		"\tclass n3,n4 external\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("mermaid lacks %q\n%s", want, out)
+		}
+	}
+}
+
+func TestExportCallGraphML(t *testing.T) {
+	var b bytes.Buffer
+	if err := ExportCallGraph(&b, exportTestGraph(), GraphML, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, b.String())
+	}
+	if len(doc.Graph.Nodes) != 5 || len(doc.Graph.Edges) != 5 {
+		t.Errorf("got %d nodes and %d edges, want 5 and 5", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+}
+
+func TestExportCallGraphUnknownFormat(t *testing.T) {
+	if err := ExportCallGraph(&bytes.Buffer{}, NewCallGraph(), GraphFormat(-1), nil); err == nil {
+		t.Error("unknown format exported without error")
+	}
+}
@@ -0,0 +1,347 @@
+package goretriever
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// TypeRef is a named type of the hierarchy.
+type TypeRef struct {
+	// ID is the package path and the name of the type.
+	ID        string
+	Pkg       string
+	Name      string
+	Interface bool
+	Pos       token.Position
+}
+
+// MethodRef is a method implementing an interface method, possibly
+// promoted from an embedded type.
+type MethodRef struct {
+	Name string
+	// ID is the id of the method, as in the call graph.
+	ID  string
+	Pos token.Position
+}
+
+// Implementation is a type implementing an interface.
+type Implementation struct {
+	Type      *TypeRef
+	Interface *TypeRef
+	// Pointer is set when only the method set of *Type implements the
+	// interface, the value method set being enough otherwise.
+	Pointer bool
+	// Methods are ordered by name.
+	Methods []*MethodRef
+}
+
+// Embedding is a type embedded in a struct or an interface.
+type Embedding struct {
+	Outer *TypeRef
+	Inner *TypeRef
+	// Pointer is set for embedded *Inner.
+	Pointer bool
+	// Pos is the position of the embedded field.
+	Pos token.Position
+}
+
+// TypeHierarchy links the named types of the loaded packages to the
+// interfaces they implement and to the types they embed.
+type TypeHierarchy struct {
+	// Types are ordered by id.
+	Types []*TypeRef
+	// Implementations are ordered by interface and type.
+	Implementations []*Implementation
+	// Embeddings are ordered by outer type and position.
+	Embeddings []*Embedding
+
+	byID map[string]*TypeRef
+}
+
+// Type returns the type with the given id, or nil.
+func (h *TypeHierarchy) Type(id string) *TypeRef {
+	return h.byID[id]
+}
+
+// ImplementationsOf returns the types of the loaded packages
+// implementing the interface id, interfaces included.
+func (h *TypeHierarchy) ImplementationsOf(id string) []*Implementation {
+	var impls []*Implementation
+	for _, impl := range h.Implementations {
+		if impl.Interface.ID == id {
+			impls = append(impls, impl)
+		}
+	}
+	return impls
+}
+
+// InterfacesOf returns the interfaces implemented by the type id.
+func (h *TypeHierarchy) InterfacesOf(id string) []*Implementation {
+	var impls []*Implementation
+	for _, impl := range h.Implementations {
+		if impl.Type.ID == id {
+			impls = append(impls, impl)
+		}
+	}
+	return impls
+}
+
+// Embeds returns the types directly embedded by the type id.
+func (h *TypeHierarchy) Embeds(id string) []*Embedding {
+	var embeddings []*Embedding
+	for _, e := range h.Embeddings {
+		if e.Outer.ID == id {
+			embeddings = append(embeddings, e)
+		}
+	}
+	return embeddings
+}
+
+// EmbeddedBy returns the types directly embedding the type id.
+func (h *TypeHierarchy) EmbeddedBy(id string) []*Embedding {
+	var embeddings []*Embedding
+	for _, e := range h.Embeddings {
+		if e.Inner.ID == id {
+			embeddings = append(embeddings, e)
+		}
+	}
+	return embeddings
+}
+
+// EmbeddingChains returns the chains of embeddings starting at the type
+// id, each of them ending with a type embedding nothing.
+func (h *TypeHierarchy) EmbeddingChains(id string) [][]*Embedding {
+	var (
+		chains [][]*Embedding
+		onPath = make(map[string]bool)
+		walk   func(id string, chain []*Embedding)
+	)
+	walk = func(id string, chain []*Embedding) {
+		embeds := h.Embeds(id)
+		if len(embeds) == 0 && len(chain) > 0 {
+			chains = append(chains, append([]*Embedding(nil), chain...))
+			return
+		}
+
+		onPath[id] = true
+		for _, e := range embeds {
+			if onPath[e.Inner.ID] {
+				// a struct may embed a pointer to itself
+				chains = append(chains, append(append([]*Embedding(nil), chain...), e))
+				continue
+			}
+			walk(e.Inner.ID, append(chain, e))
+		}
+		onPath[id] = false
+	}
+	walk(id, nil)
+	return chains
+}
+
+func (h *TypeHierarchy) ref(a *Analyzer, named *types.Named) *TypeRef {
+	id := typeID(named)
+	if t, ok := h.byID[id]; ok {
+		return t
+	}
+
+	obj := named.Origin().Obj()
+	t := &TypeRef{
+		ID:        id,
+		Name:      obj.Name(),
+		Interface: types.IsInterface(named),
+		Pos:       a.Fset.Position(obj.Pos()),
+	}
+	if obj.Pkg() != nil {
+		t.Pkg = obj.Pkg().Path()
+	}
+	h.byID[id] = t
+	h.Types = append(h.Types, t)
+	return t
+}
+
+// hierarchyInterfaces returns the non generic, non empty named
+// interfaces declared in the loaded packages and in the packages they
+// import, and error, ordered by name.
+func (a *Analyzer) hierarchyInterfaces() []*types.Named {
+	var (
+		seen   = make(map[*types.Named]bool)
+		ifaces []*types.Named
+	)
+	add := func(obj types.Object) {
+		tn, ok := obj.(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			return
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || seen[named] || named.TypeParams().Len() > 0 {
+			return
+		}
+		iface, ok := named.Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 || !iface.IsMethodSet() {
+			return
+		}
+		seen[named] = true
+		ifaces = append(ifaces, named)
+	}
+
+	add(types.Universe.Lookup("error"))
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, def := range pkg.TypesInfo.Defs {
+			add(def)
+		}
+		if pkg.Types == nil {
+			continue
+		}
+		for _, imported := range pkg.Types.Imports() {
+			scope := imported.Scope()
+			for _, name := range scope.Names() {
+				if obj := scope.Lookup(name); obj.Exported() {
+					add(obj)
+				}
+			}
+		}
+	}
+
+	sort.Slice(ifaces, func(i, j int) bool { return typeID(ifaces[i]) < typeID(ifaces[j]) })
+	return ifaces
+}
+
+// TypeHierarchy builds the type hierarchy of the loaded packages. Types
+// are checked against the interfaces of the loaded packages, of the
+// packages they import and error; interfaces of the loaded packages are
+// checked against the other interfaces too.
+func (a *Analyzer) TypeHierarchy() *TypeHierarchy {
+	h := &TypeHierarchy{byID: make(map[string]*TypeRef)}
+	ifaces := a.hierarchyInterfaces()
+
+	var candidates []*types.Named
+	for _, t := range a.concreteTypes() {
+		candidates = append(candidates, t.(*types.Named))
+	}
+	for _, iface := range ifaces {
+		if iface.Obj().Pkg() != nil && a.isLoaded(iface.Obj().Pkg().Path()) {
+			candidates = append(candidates, iface)
+		}
+	}
+
+	for _, iface := range ifaces {
+		it := iface.Underlying().(*types.Interface)
+		for _, t := range candidates {
+			if t == iface {
+				continue
+			}
+
+			var pointer bool
+			switch {
+			case types.Implements(t, it):
+			case !types.IsInterface(t) && types.Implements(types.NewPointer(t), it):
+				pointer = true
+			default:
+				continue
+			}
+
+			impl := &Implementation{Type: h.ref(a, t), Interface: h.ref(a, iface), Pointer: pointer}
+			for i := 0; i < it.NumMethods(); i++ {
+				m := it.Method(i)
+				obj, _, _ := types.LookupFieldOrMethod(t, true, m.Pkg(), m.Name())
+				fn, ok := obj.(*types.Func)
+				if !ok {
+					continue
+				}
+				impl.Methods = append(impl.Methods, &MethodRef{
+					Name: fn.Name(),
+					ID:   FuncID(fn),
+					Pos:  a.Fset.Position(fn.Pos()),
+				})
+			}
+			sort.Slice(impl.Methods, func(i, j int) bool { return impl.Methods[i].Name < impl.Methods[j].Name })
+			h.Implementations = append(h.Implementations, impl)
+		}
+	}
+
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				tn, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
+				if !ok {
+					return true
+				}
+				outer, ok := tn.Type().(*types.Named)
+				if !ok {
+					return true
+				}
+
+				var fields *ast.FieldList
+				switch typ := spec.Type.(type) {
+				case *ast.StructType:
+					fields = typ.Fields
+				case *ast.InterfaceType:
+					fields = typ.Methods
+				}
+				if fields == nil {
+					return true
+				}
+
+				for _, field := range fields.List {
+					if len(field.Names) > 0 {
+						continue
+					}
+					t := pkg.TypesInfo.TypeOf(field.Type)
+					ptr, pointer := t.(*types.Pointer)
+					if pointer {
+						t = ptr.Elem()
+					}
+					inner, ok := t.(*types.Named)
+					if !ok {
+						continue
+					}
+					h.Embeddings = append(h.Embeddings, &Embedding{
+						Outer:   h.ref(a, outer),
+						Inner:   h.ref(a, inner),
+						Pointer: pointer,
+						Pos:     a.Fset.Position(field.Type.Pos()),
+					})
+				}
+				return true
+			})
+		}
+	}
+
+	sort.Slice(h.Types, func(i, j int) bool { return h.Types[i].ID < h.Types[j].ID })
+	sort.SliceStable(h.Implementations, func(i, j int) bool {
+		if h.Implementations[i].Interface.ID != h.Implementations[j].Interface.ID {
+			return h.Implementations[i].Interface.ID < h.Implementations[j].Interface.ID
+		}
+		return h.Implementations[i].Type.ID < h.Implementations[j].Type.ID
+	})
+	sort.SliceStable(h.Embeddings, func(i, j int) bool {
+		if h.Embeddings[i].Outer.ID != h.Embeddings[j].Outer.ID {
+			return h.Embeddings[i].Outer.ID < h.Embeddings[j].Outer.ID
+		}
+		return positionLess(h.Embeddings[i].Pos, h.Embeddings[j].Pos)
+	})
+	return h
+}
+
+// isLoaded reports whether the package path is one of the loaded
+// packages.
+func (a *Analyzer) isLoaded(path string) bool {
+	for _, pkg := range a.Packages {
+		if pkg.PkgPath == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const hierarchySource = `package rw
+
+type Reader interface{ Read(p []byte) (int, error) }
+
+type Writer interface{ Write(p []byte) (int, error) }
+
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
+type File struct{}
+
+func (f *File) Read(p []byte) (int, error)  { return 0, nil }
+func (f *File) Write(p []byte) (int, error) { return 0, nil }
+
+type Buffer struct{}
+
+func (Buffer) Read(p []byte) (int, error) { return 0, nil }
+
+// Logged gets Read and Write from the embedded *File.
+type Logged struct {
+	*File
+	name string
+}
+
+type Err struct{}
+
+func (Err) Error() string { return "" }
+
+type Node struct {
+	*Node
+	Buffer
+}
+`
+
+func TestTypeHierarchy(t *testing.T) {
+	dir := writeModule(t, map[string]string{"rw/rw.go": hierarchySource})
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := a.TypeHierarchy()
+
+	trim := func(id string) string { return strings.ReplaceAll(id, "example.com/m/rw.", "") }
+	impls := func(list []*Implementation) string {
+		var s []string
+		for _, impl := range list {
+			typ := trim(impl.Type.ID)
+			if impl.Pointer {
+				typ = "*" + typ
+			}
+			s = append(s, typ+":"+trim(impl.Interface.ID))
+		}
+		return strings.Join(s, " ")
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"implementations of Reader", impls(h.ImplementationsOf("example.com/m/rw.Reader")),
+			"Buffer:Reader *File:Reader Logged:Reader Node:Reader ReadWriter:Reader"},
+		{"implementations of ReadWriter", impls(h.ImplementationsOf("example.com/m/rw.ReadWriter")),
+			"*File:ReadWriter Logged:ReadWriter"},
+		{"interfaces of File", impls(h.InterfacesOf("example.com/m/rw.File")),
+			"*File:ReadWriter *File:Reader *File:Writer"},
+		{"interfaces of Err", impls(h.InterfacesOf("example.com/m/rw.Err")),
+			"Err:error"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s are %q, want %q", test.name, test.got, test.want)
+		}
+	}
+
+	for _, impl := range h.InterfacesOf("example.com/m/rw.Logged") {
+		for _, m := range impl.Methods {
+			if !strings.HasPrefix(m.ID, "(*example.com/m/rw.File).") || m.Pos.Line == 0 {
+				t.Errorf("%s of Logged is %s at %s", m.Name, m.ID, m.Pos)
+			}
+		}
+	}
+
+	var chains []string
+	for _, id := range []string{"example.com/m/rw.ReadWriter", "example.com/m/rw.Logged", "example.com/m/rw.Node"} {
+		for _, chain := range h.EmbeddingChains(id) {
+			s := trim(chain[0].Outer.ID)
+			for _, e := range chain {
+				inner := trim(e.Inner.ID)
+				if e.Pointer {
+					inner = "*" + inner
+				}
+				s += fmt.Sprintf(" > %s:%d", inner, e.Pos.Line)
+			}
+			chains = append(chains, s)
+		}
+	}
+	want := []string{
+		"ReadWriter > Reader:8",
+		"ReadWriter > Writer:9",
+		"Logged > *File:23",
+		// a struct embedding a pointer to itself ends the chain
+		"Node > *Node:32",
+		"Node > Buffer:33",
+	}
+	if strings.Join(chains, "\n") != strings.Join(want, "\n") {
+		t.Errorf("chains are\n%s\nwant\n%s", strings.Join(chains, "\n"), strings.Join(want, "\n"))
+	}
+
+	if by := h.EmbeddedBy("example.com/m/rw.Buffer"); len(by) != 1 || by[0].Outer.ID != "example.com/m/rw.Node" {
+		t.Errorf("Buffer is embedded by %v", by)
+	}
+	if file := h.Type("example.com/m/rw.File"); file == nil || file.Interface || file.Pos.Line != 12 {
+		t.Errorf("File is %+v", file)
+	}
+}
@@ -0,0 +1,807 @@
+package goretriever
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Index file layout, all integers are little endian:
+//
+//	header    magic "GRIX", version, section count, directory crc
+//	directory one entry per section: kind, crc, offset, length
+//	sections  fixed size records referring to the strings and code
+//	          sections by (offset, length) pairs
+//
+// Packages refer to their imports by a range of the imports section,
+// type symbols to their fields or interface methods by a range of the
+// fields section.
+const (
+	indexMagic   = "GRIX"
+	indexVersion = 3
+
+	indexHeaderSize   = 16
+	indexDirEntrySize = 24
+
+	indexPackageSize = 40
+	indexSymbolSize  = 104
+	indexFieldSize   = 28
+	indexImportSize  = 8
+	indexNodeSize    = 24
+	indexEdgeSize    = 8
+)
+
+// Flags of symbol and field records.
+const (
+	indexFlagInterface uint32 = 1 << iota
+	indexFlagEmbedded
+	indexFlagMetrics
+)
+
+const (
+	sectionStrings uint32 = iota + 1
+	sectionCode
+	sectionPackages
+	sectionSymbols
+	sectionNames
+	sectionNodes
+	sectionEdges
+	sectionRevEdges
+	sectionFields
+	sectionImports
+	sectionCount = sectionImports
+)
+
+var (
+	ErrIndexVersion = errors.New("unsupported index version")
+	ErrIndexCorrupt = errors.New("corrupted index file")
+)
+
+var indexCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+type indexWriter struct {
+	strs    bytes.Buffer
+	strRefs map[string][2]uint32
+	code    bytes.Buffer
+	err     error
+}
+
+func (w *indexWriter) ref(buf *bytes.Buffer, s string) [2]uint32 {
+	if uint64(buf.Len())+uint64(len(s)) > math.MaxUint32 {
+		w.err = errors.New("index section exceeds 4GB")
+		return [2]uint32{}
+	}
+	r := [2]uint32{uint32(buf.Len()), uint32(len(s))}
+	buf.WriteString(s)
+	return r
+}
+
+func (w *indexWriter) str(s string) [2]uint32 {
+	if r, ok := w.strRefs[s]; ok {
+		return r
+	}
+	r := w.ref(&w.strs, s)
+	w.strRefs[s] = r
+	return r
+}
+
+func putRef(b []byte, r [2]uint32) {
+	binary.LittleEndian.PutUint32(b, r[0])
+	binary.LittleEndian.PutUint32(b[4:], r[1])
+}
+
+// putMetrics stores m into the function symbol record rec.
+func putMetrics(rec []byte, m *Metrics) {
+	if m == nil {
+		return
+	}
+	binary.LittleEndian.PutUint32(rec[64:], indexFlagMetrics)
+	for i, v := range []int{m.Cyclomatic, m.Cognitive, m.Nesting, m.Lines, m.Params, m.FanIn, m.FanOut} {
+		binary.LittleEndian.PutUint32(rec[76+4*i:], uint32(v))
+	}
+}
+
+func getMetrics(rec []byte) *Metrics {
+	if binary.LittleEndian.Uint32(rec[64:])&indexFlagMetrics == 0 {
+		return nil
+	}
+	v := func(i int) int {
+		return int(binary.LittleEndian.Uint32(rec[76+4*i:]))
+	}
+	return &Metrics{
+		Cyclomatic: v(0),
+		Cognitive:  v(1),
+		Nesting:    v(2),
+		Lines:      v(3),
+		Params:     v(4),
+		FanIn:      v(5),
+		FanOut:     v(6),
+	}
+}
+
+// WriteIndex serialises the packages and the call graph into the
+// binary index format read by OpenIndex.
+func WriteIndex(out io.Writer, pkgs []*Package, callGraph *CallGraph) error {
+	w := &indexWriter{strRefs: make(map[string][2]uint32)}
+
+	var (
+		packages []byte
+		symbols  []byte
+		fields   []byte
+		imports  []byte
+		allSyms  []*Symbol
+	)
+	for i, pkg := range pkgs {
+		syms := pkg.Symbols()
+
+		rec := make([]byte, indexPackageSize)
+		putRef(rec, w.str(pkg.Name))
+		putRef(rec[8:], w.str(pkg.Dir))
+		putRef(rec[16:], w.str(pkg.Path))
+		binary.LittleEndian.PutUint32(rec[24:], uint32(len(allSyms)))
+		binary.LittleEndian.PutUint32(rec[28:], uint32(len(syms)))
+		binary.LittleEndian.PutUint32(rec[32:], uint32(len(imports)/indexImportSize))
+		binary.LittleEndian.PutUint32(rec[36:], uint32(len(pkg.Imports)))
+		packages = append(packages, rec...)
+
+		for _, imp := range pkg.Imports {
+			ref := make([]byte, indexImportSize)
+			putRef(ref, w.str(imp))
+			imports = append(imports, ref...)
+		}
+
+		for _, s := range syms {
+			rec := make([]byte, indexSymbolSize)
+			binary.LittleEndian.PutUint32(rec, uint32(s.Kind))
+			binary.LittleEndian.PutUint32(rec[4:], uint32(i))
+			putRef(rec[8:], w.str(s.Name))
+			putRef(rec[16:], w.str(s.Recv))
+			putRef(rec[24:], w.str(s.Doc))
+			putRef(rec[32:], w.str(s.File))
+			binary.LittleEndian.PutUint32(rec[40:], uint32(s.Line))
+			putRef(rec[48:], w.ref(&w.code, s.Code))
+
+			switch s.Kind {
+			case SymbolType:
+				st := pkg.Structs[s.Name]
+				if st.Interface {
+					binary.LittleEndian.PutUint32(rec[64:], indexFlagInterface)
+				}
+				binary.LittleEndian.PutUint32(rec[68:], uint32(len(fields)/indexFieldSize))
+				binary.LittleEndian.PutUint32(rec[72:], uint32(len(st.Fields)))
+				for _, f := range st.Fields {
+					field := make([]byte, indexFieldSize)
+					putRef(field, w.str(f.Name))
+					putRef(field[8:], w.str(f.Type))
+					putRef(field[16:], w.str(f.Tag))
+					if f.Embedded {
+						binary.LittleEndian.PutUint32(field[24:], indexFlagEmbedded)
+					}
+					fields = append(fields, field...)
+				}
+			case SymbolMethod:
+				m := pkg.Structs[s.Recv].Methods[s.Name]
+				putRef(rec[56:], w.str(m.Recv))
+				putMetrics(rec, m.Metrics)
+			case SymbolFunction:
+				putMetrics(rec, pkg.Functions[s.Name].Metrics)
+			}
+			symbols = append(symbols, rec...)
+		}
+		allSyms = append(allSyms, syms...)
+	}
+
+	names := make([]uint32, len(allSyms))
+	for i := range names {
+		names[i] = uint32(i)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return allSyms[names[i]].Name < allSyms[names[j]].Name
+	})
+	nameBytes := make([]byte, 4*len(names))
+	for i, n := range names {
+		binary.LittleEndian.PutUint32(nameBytes[4*i:], n)
+	}
+
+	var (
+		graphNodes []*CallNode
+		graphEdges []*CallEdge
+	)
+	if callGraph != nil {
+		graphNodes = callGraph.Nodes()
+		graphEdges = callGraph.Edges()
+	}
+
+	nodeIndex := make(map[*CallNode]uint32, len(graphNodes))
+	nodes := make([]byte, 0, indexNodeSize*len(graphNodes))
+	for i, n := range graphNodes {
+		nodeIndex[n] = uint32(i)
+
+		rec := make([]byte, indexNodeSize)
+		putRef(rec, w.str(n.ID))
+		putRef(rec[8:], w.str(n.Signature))
+		putRef(rec[16:], w.ref(&w.code, n.Code))
+		nodes = append(nodes, rec...)
+	}
+
+	edges := make([][2]uint32, 0, len(graphEdges))
+	for _, e := range graphEdges {
+		edges = append(edges, [2]uint32{nodeIndex[e.Caller], nodeIndex[e.Callee]})
+	}
+	encodeEdges := func(from, to int) []byte {
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i][from] != edges[j][from] {
+				return edges[i][from] < edges[j][from]
+			}
+			return edges[i][to] < edges[j][to]
+		})
+		b := make([]byte, indexEdgeSize*len(edges))
+		for i, e := range edges {
+			binary.LittleEndian.PutUint32(b[indexEdgeSize*i:], e[from])
+			binary.LittleEndian.PutUint32(b[indexEdgeSize*i+4:], e[to])
+		}
+		return b
+	}
+	forward := encodeEdges(0, 1)
+	backward := encodeEdges(1, 0)
+
+	if w.err != nil {
+		return w.err
+	}
+
+	sections := [][]byte{
+		sectionStrings - 1:  w.strs.Bytes(),
+		sectionCode - 1:     w.code.Bytes(),
+		sectionPackages - 1: packages,
+		sectionSymbols - 1:  symbols,
+		sectionNames - 1:    nameBytes,
+		sectionNodes - 1:    nodes,
+		sectionEdges - 1:    forward,
+		sectionRevEdges - 1: backward,
+		sectionFields - 1:   fields,
+		sectionImports - 1:  imports,
+	}
+
+	dir := make([]byte, indexDirEntrySize*len(sections))
+	offset := uint64(indexHeaderSize + len(dir))
+	for i, data := range sections {
+		entry := dir[indexDirEntrySize*i:]
+		binary.LittleEndian.PutUint32(entry, uint32(i+1))
+		binary.LittleEndian.PutUint32(entry[4:], crc32.Checksum(data, indexCRCTable))
+		binary.LittleEndian.PutUint64(entry[8:], offset)
+		binary.LittleEndian.PutUint64(entry[16:], uint64(len(data)))
+		offset += uint64(len(data))
+	}
+
+	header := make([]byte, indexHeaderSize)
+	copy(header, indexMagic)
+	binary.LittleEndian.PutUint32(header[4:], indexVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(sections)))
+	binary.LittleEndian.PutUint32(header[12:], crc32.Checksum(dir, indexCRCTable))
+
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	if _, err := out.Write(dir); err != nil {
+		return err
+	}
+	for _, data := range sections {
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveIndex writes the index into the file at path.
+func SaveIndex(path string, pkgs []*Package, callGraph *CallGraph) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	err = WriteIndex(file, pkgs, callGraph)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// DiskIndex is a read only view of an index file. Records are decoded
+// on demand straight from the memory mapped file, the checksum of a
+// section is checked the first time it is read.
+type DiskIndex struct {
+	data     []byte
+	sections [sectionCount][]byte
+	crcs     [sectionCount]uint32
+	checks   [sectionCount]sync.Once
+	errs     [sectionCount]error
+}
+
+// OpenIndex memory maps the index file at path and validates its
+// header and section directory.
+func OpenIndex(path string) (*DiskIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < indexHeaderSize {
+		return nil, fmt.Errorf("%w: file too small", ErrIndexCorrupt)
+	}
+	if info.Size() > math.MaxInt {
+		return nil, fmt.Errorf("%w: file too large", ErrIndexCorrupt)
+	}
+
+	data, err := mmapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &DiskIndex{data: data}
+	if err := idx.init(); err != nil {
+		munmapFile(data)
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *DiskIndex) init() error {
+	data := idx.data
+	if string(data[:4]) != indexMagic {
+		return fmt.Errorf("%w: bad magic", ErrIndexCorrupt)
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != indexVersion {
+		return fmt.Errorf("%w: got %d, want %d", ErrIndexVersion, v, indexVersion)
+	}
+
+	count := uint64(binary.LittleEndian.Uint32(data[8:]))
+	if count != uint64(sectionCount) {
+		return fmt.Errorf("%w: got %d sections, want %d", ErrIndexCorrupt, count, sectionCount)
+	}
+	dirEnd := indexHeaderSize + count*indexDirEntrySize
+	if dirEnd > uint64(len(data)) {
+		return fmt.Errorf("%w: truncated directory", ErrIndexCorrupt)
+	}
+	dir := data[indexHeaderSize:dirEnd]
+	if crc32.Checksum(dir, indexCRCTable) != binary.LittleEndian.Uint32(data[12:]) {
+		return fmt.Errorf("%w: directory checksum mismatch", ErrIndexCorrupt)
+	}
+
+	for i := uint64(0); i < count; i++ {
+		entry := dir[i*indexDirEntrySize:]
+		kind := binary.LittleEndian.Uint32(entry)
+		crc := binary.LittleEndian.Uint32(entry[4:])
+		off := binary.LittleEndian.Uint64(entry[8:])
+		size := binary.LittleEndian.Uint64(entry[16:])
+
+		if kind != uint32(i+1) {
+			return fmt.Errorf("%w: unexpected section %d", ErrIndexCorrupt, kind)
+		}
+		if off < dirEnd || off > uint64(len(data)) || size > uint64(len(data))-off {
+			return fmt.Errorf("%w: section %d out of bounds", ErrIndexCorrupt, kind)
+		}
+		idx.sections[i] = data[off : off+size]
+		idx.crcs[i] = crc
+	}
+
+	recordSizes := map[uint32]int{
+		sectionPackages: indexPackageSize,
+		sectionSymbols:  indexSymbolSize,
+		sectionNames:    4,
+		sectionNodes:    indexNodeSize,
+		sectionEdges:    indexEdgeSize,
+		sectionRevEdges: indexEdgeSize,
+		sectionFields:   indexFieldSize,
+		sectionImports:  indexImportSize,
+	}
+	for kind, size := range recordSizes {
+		if len(idx.sections[kind-1])%size != 0 {
+			return fmt.Errorf("%w: section %d has a partial record", ErrIndexCorrupt, kind)
+		}
+	}
+	if len(idx.sections[sectionNames-1])/4 != idx.NumSymbols() {
+		return fmt.Errorf("%w: name table does not match symbols", ErrIndexCorrupt)
+	}
+	if len(idx.sections[sectionEdges-1]) != len(idx.sections[sectionRevEdges-1]) {
+		return fmt.Errorf("%w: edge tables do not match", ErrIndexCorrupt)
+	}
+	return nil
+}
+
+// Close unmaps the index file. Symbols and descriptors returned
+// before stay valid.
+func (idx *DiskIndex) Close() error {
+	if idx.data == nil {
+		return nil
+	}
+	err := munmapFile(idx.data)
+	idx.data = nil
+	idx.sections = [sectionCount][]byte{}
+	return err
+}
+
+// section returns the section of the given kind, checking its checksum
+// the first time.
+func (idx *DiskIndex) section(kind uint32) ([]byte, error) {
+	idx.checks[kind-1].Do(func() {
+		if crc32.Checksum(idx.sections[kind-1], indexCRCTable) != idx.crcs[kind-1] {
+			idx.errs[kind-1] = fmt.Errorf("%w: section %d checksum mismatch", ErrIndexCorrupt, kind)
+		}
+	})
+	return idx.sections[kind-1], idx.errs[kind-1]
+}
+
+// Verify checks the checksums of all sections, which are otherwise
+// checked on first use.
+func (idx *DiskIndex) Verify() error {
+	for kind := uint32(1); kind <= sectionCount; kind++ {
+		if _, err := idx.section(kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *DiskIndex) record(kind uint32, size, i int) ([]byte, error) {
+	data, err := idx.section(kind)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || (i+1)*size > len(data) {
+		return nil, fmt.Errorf("%w: record %d of section %d out of bounds", ErrIndexCorrupt, i, kind)
+	}
+	return data[i*size : (i+1)*size], nil
+}
+
+func (idx *DiskIndex) bytesAt(kind uint32, ref []byte) ([]byte, error) {
+	data, err := idx.section(kind)
+	if err != nil {
+		return nil, err
+	}
+	off := uint64(binary.LittleEndian.Uint32(ref))
+	size := uint64(binary.LittleEndian.Uint32(ref[4:]))
+	if off+size > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: reference out of bounds in section %d", ErrIndexCorrupt, kind)
+	}
+	return data[off : off+size], nil
+}
+
+func (idx *DiskIndex) stringAt(ref []byte) (string, error) {
+	b, err := idx.bytesAt(sectionStrings, ref)
+	return string(b), err
+}
+
+func (idx *DiskIndex) indexAt(kind uint32, size, i int) (uint32, error) {
+	rec, err := idx.record(kind, size, i)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(rec), nil
+}
+
+// NumPackages returns the number of packages stored in the index.
+func (idx *DiskIndex) NumPackages() int {
+	return len(idx.sections[sectionPackages-1]) / indexPackageSize
+}
+
+// NumSymbols returns the number of symbols stored in the index.
+func (idx *DiskIndex) NumSymbols() int {
+	return len(idx.sections[sectionSymbols-1]) / indexSymbolSize
+}
+
+// NumNodes returns the number of call graph nodes stored in the index.
+func (idx *DiskIndex) NumNodes() int {
+	return len(idx.sections[sectionNodes-1]) / indexNodeSize
+}
+
+// Package rebuilds the i-th package together with its symbols.
+func (idx *DiskIndex) Package(i int) (*Package, error) {
+	rec, err := idx.record(sectionPackages, indexPackageSize, i)
+	if err != nil {
+		return nil, err
+	}
+	name, err := idx.stringAt(rec)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := idx.stringAt(rec[8:])
+	if err != nil {
+		return nil, err
+	}
+	path, err := idx.stringAt(rec[16:])
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := NewPackage(name)
+	pkg.Dir = dir
+	pkg.Path = path
+
+	firstImport := int(binary.LittleEndian.Uint32(rec[32:]))
+	importCount := int(binary.LittleEndian.Uint32(rec[36:]))
+	for j := firstImport; j < firstImport+importCount; j++ {
+		ref, err := idx.record(sectionImports, indexImportSize, j)
+		if err != nil {
+			return nil, err
+		}
+		imp, err := idx.stringAt(ref)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Imports = append(pkg.Imports, imp)
+	}
+
+	first := int(binary.LittleEndian.Uint32(rec[24:]))
+	count := int(binary.LittleEndian.Uint32(rec[28:]))
+	for j := first; j < first+count; j++ {
+		s, err := idx.Symbol(j)
+		if err != nil {
+			return nil, err
+		}
+		symRec, err := idx.record(sectionSymbols, indexSymbolSize, j)
+		if err != nil {
+			return nil, err
+		}
+		switch s.Kind {
+		case SymbolType:
+			fields, err := idx.fields(symRec)
+			if err != nil {
+				return nil, err
+			}
+			pkg.AddStruct(&Struct{
+				Name:      s.Name,
+				Code:      s.Code,
+				Doc:       s.Doc,
+				File:      s.File,
+				Line:      s.Line,
+				Interface: binary.LittleEndian.Uint32(symRec[64:])&indexFlagInterface != 0,
+				Fields:    fields,
+				Methods:   make(map[string]*Function),
+			})
+		case SymbolMethod, SymbolFunction:
+			f := &Function{
+				Name:    s.Name,
+				Code:    s.Code,
+				Doc:     s.Doc,
+				File:    s.File,
+				Line:    s.Line,
+				Metrics: getMetrics(symRec),
+			}
+			if s.Kind == SymbolMethod {
+				if f.Recv, err = idx.stringAt(symRec[56:]); err != nil {
+					return nil, err
+				}
+				pkg.AddMethod(s.Recv, f)
+			} else {
+				pkg.AddFunction(f)
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// fields decodes the fields of the type symbol record rec.
+func (idx *DiskIndex) fields(rec []byte) ([]*Field, error) {
+	first := int(binary.LittleEndian.Uint32(rec[68:]))
+	count := int(binary.LittleEndian.Uint32(rec[72:]))
+
+	var fields []*Field
+	for i := first; i < first+count; i++ {
+		frec, err := idx.record(sectionFields, indexFieldSize, i)
+		if err != nil {
+			return nil, err
+		}
+		f := &Field{Embedded: binary.LittleEndian.Uint32(frec[24:])&indexFlagEmbedded != 0}
+		if f.Name, err = idx.stringAt(frec); err != nil {
+			return nil, err
+		}
+		if f.Type, err = idx.stringAt(frec[8:]); err != nil {
+			return nil, err
+		}
+		if f.Tag, err = idx.stringAt(frec[16:]); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Symbol decodes the i-th symbol.
+func (idx *DiskIndex) Symbol(i int) (*Symbol, error) {
+	rec, err := idx.record(sectionSymbols, indexSymbolSize, i)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Symbol{
+		Kind: SymbolKind(binary.LittleEndian.Uint32(rec)),
+		Line: int(binary.LittleEndian.Uint32(rec[40:])),
+	}
+
+	pkg, err := idx.record(sectionPackages, indexPackageSize, int(binary.LittleEndian.Uint32(rec[4:])))
+	if err != nil {
+		return nil, err
+	}
+	if s.Package, err = idx.stringAt(pkg); err != nil {
+		return nil, err
+	}
+	if s.Dir, err = idx.stringAt(pkg[8:]); err != nil {
+		return nil, err
+	}
+	if s.Path, err = idx.stringAt(pkg[16:]); err != nil {
+		return nil, err
+	}
+	if s.Name, err = idx.stringAt(rec[8:]); err != nil {
+		return nil, err
+	}
+	if s.Recv, err = idx.stringAt(rec[16:]); err != nil {
+		return nil, err
+	}
+	if s.Doc, err = idx.stringAt(rec[24:]); err != nil {
+		return nil, err
+	}
+	if s.File, err = idx.stringAt(rec[32:]); err != nil {
+		return nil, err
+	}
+	code, err := idx.bytesAt(sectionCode, rec[48:])
+	if err != nil {
+		return nil, err
+	}
+	s.Code = string(code)
+	return s, nil
+}
+
+// LookupSymbol returns all symbols with the given name, regardless
+// of their package or receiver.
+func (idx *DiskIndex) LookupSymbol(name string) ([]*Symbol, error) {
+	var (
+		n      = idx.NumSymbols()
+		keyErr error
+	)
+	key := func(i int) []byte {
+		sym, err := idx.indexAt(sectionNames, 4, i)
+		if err == nil {
+			var rec []byte
+			rec, err = idx.record(sectionSymbols, indexSymbolSize, int(sym))
+			if err == nil {
+				var b []byte
+				b, err = idx.bytesAt(sectionStrings, rec[8:])
+				if err == nil {
+					return b
+				}
+			}
+		}
+		keyErr = err
+		return nil
+	}
+
+	var symbols []*Symbol
+	beg := sort.Search(n, func(i int) bool { return string(key(i)) >= name })
+	for i := beg; i < n && keyErr == nil && string(key(i)) == name; i++ {
+		sym, err := idx.indexAt(sectionNames, 4, i)
+		if err != nil {
+			return nil, err
+		}
+		s, err := idx.Symbol(int(sym))
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, keyErr
+}
+
+// Node decodes the i-th call graph node.
+func (idx *DiskIndex) Node(i int) (*FuncDescriptor, error) {
+	rec, err := idx.record(sectionNodes, indexNodeSize, i)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := &FuncDescriptor{}
+	if fd.Id, err = idx.stringAt(rec); err != nil {
+		return nil, err
+	}
+	if fd.DeclType, err = idx.stringAt(rec[8:]); err != nil {
+		return nil, err
+	}
+	code, err := idx.bytesAt(sectionCode, rec[16:])
+	if err != nil {
+		return nil, err
+	}
+	fd.Code = string(code)
+	return fd, nil
+}
+
+// findNode returns the position of the node with the given id, or -1.
+func (idx *DiskIndex) findNode(id string) (int, error) {
+	var keyErr error
+	n := idx.NumNodes()
+	i := sort.Search(n, func(i int) bool {
+		rec, err := idx.record(sectionNodes, indexNodeSize, i)
+		if err == nil {
+			var b []byte
+			if b, err = idx.bytesAt(sectionStrings, rec); err == nil {
+				return string(b) >= id
+			}
+		}
+		keyErr = err
+		return true
+	})
+	if keyErr != nil {
+		return -1, keyErr
+	}
+	if i == n {
+		return -1, nil
+	}
+	fd, err := idx.Node(i)
+	if err != nil {
+		return -1, err
+	}
+	if fd.Id != id {
+		return -1, nil
+	}
+	return i, nil
+}
+
+// LookupNode returns the call graph node with the given id, or nil.
+func (idx *DiskIndex) LookupNode(id string) (*FuncDescriptor, error) {
+	i, err := idx.findNode(id)
+	if err != nil || i < 0 {
+		return nil, err
+	}
+	return idx.Node(i)
+}
+
+func (idx *DiskIndex) adjacent(kind uint32, id string) ([]*FuncDescriptor, error) {
+	node, err := idx.findNode(id)
+	if err != nil || node < 0 {
+		return nil, err
+	}
+
+	data, err := idx.section(kind)
+	if err != nil {
+		return nil, err
+	}
+	n := len(data) / indexEdgeSize
+	beg := sort.Search(n, func(i int) bool {
+		return binary.LittleEndian.Uint32(data[i*indexEdgeSize:]) >= uint32(node)
+	})
+
+	var result []*FuncDescriptor
+	for i := beg; i < n; i++ {
+		rec := data[i*indexEdgeSize:]
+		if binary.LittleEndian.Uint32(rec) != uint32(node) {
+			break
+		}
+		fd, err := idx.Node(int(binary.LittleEndian.Uint32(rec[4:])))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fd)
+	}
+	return result, nil
+}
+
+// Callees returns the functions called by the node with the given id.
+func (idx *DiskIndex) Callees(id string) ([]*FuncDescriptor, error) {
+	return idx.adjacent(sectionEdges, id)
+}
+
+// Callers returns the functions calling the node with the given id.
+func (idx *DiskIndex) Callers(id string) ([]*FuncDescriptor, error) {
+	return idx.adjacent(sectionRevEdges, id)
+}
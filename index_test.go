@@ -0,0 +1,218 @@
+package goretriever
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const indexSource = `package store
+
+import (
+	"io"
+	"sync"
+)
+
+// Store keeps values by key.
+type Store struct {
+	sync.Mutex
+	values map[string]int ` + "`json:\"values\"`" + `
+}
+
+// Getter reads values.
+type Getter interface {
+	io.Closer
+	Get(key string) (int, bool)
+}
+
+// Get returns the value of key.
+func (s *Store) Get(key string) (int, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s Store) Len() int { return len(s.values) }
+
+// New returns an empty store.
+func New() *Store {
+	if true {
+		return &Store{values: map[string]int{}}
+	}
+	return nil
+}
+`
+
+func writeTestIndex(t *testing.T, pkgs []*Package, callGraph *CallGraph) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "index.grix")
+	if err := SaveIndex(path, pkgs, callGraph); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIndexPackageRoundTrip(t *testing.T) {
+	pkg, err := ParseString("store", indexSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.Path = "example.com/store"
+	pkg.Dir = "store"
+
+	idx, err := OpenIndex(writeTestIndex(t, []*Package{pkg}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if err := idx.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if n := idx.NumPackages(); n != 1 {
+		t.Fatalf("got %d packages, want 1", n)
+	}
+	got, err := idx.Package(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := json.MarshalIndent(pkg, "", "  ")
+	have, _ := json.MarshalIndent(got, "", "  ")
+	if string(have) != string(want) {
+		t.Errorf("package read back differs\ngot:\n%s\nwant:\n%s", have, want)
+	}
+
+	if recv := got.Structs["Store"].Methods["Get"].Recv; recv != "*Store" {
+		t.Errorf("Get has receiver %q, want *Store", recv)
+	}
+	if !got.Structs["Getter"].Interface {
+		t.Error("Getter is not an interface")
+	}
+	if fields := got.Structs["Store"].Fields; len(fields) != 2 || !fields[0].Embedded {
+		t.Errorf("Store fields are %v, want an embedded field and values", fields)
+	}
+}
+
+func TestIndexCallGraph(t *testing.T) {
+	g := NewCallGraph()
+	main := g.AddNode("example.com/m.main")
+	run := g.AddNode("example.com/m.run")
+	stop := g.AddNode("example.com/m.stop")
+	main.Signature = "func()"
+	run.Code = "func run() { stop() }"
+	g.AddEdge(main, run)
+	g.AddEdge(main, stop)
+	g.AddEdge(run, stop)
+
+	idx, err := OpenIndex(writeTestIndex(t, nil, g))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	node, err := idx.LookupNode("example.com/m.run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node == nil || node.Code != run.Code {
+		t.Fatalf("LookupNode(run) = %+v", node)
+	}
+	if node, _ := idx.LookupNode("example.com/m.missing"); node != nil {
+		t.Errorf("LookupNode(missing) = %+v, want nil", node)
+	}
+
+	ids := func(fds []*FuncDescriptor, err error) []string {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, fd := range fds {
+			ids = append(ids, fd.Id)
+		}
+		return ids
+	}
+	if got := ids(idx.Callees("example.com/m.main")); len(got) != 2 || got[0] != "example.com/m.run" || got[1] != "example.com/m.stop" {
+		t.Errorf("callees of main are %v", got)
+	}
+	if got := ids(idx.Callers("example.com/m.stop")); len(got) != 2 || got[0] != "example.com/m.main" || got[1] != "example.com/m.run" {
+		t.Errorf("callers of stop are %v", got)
+	}
+}
+
+func TestIndexLookupSymbol(t *testing.T) {
+	pkg, err := ParseString("store", indexSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := OpenIndex(writeTestIndex(t, []*Package{pkg}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	syms, err := idx.LookupSymbol("Get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 || syms[0].Kind != SymbolMethod || syms[0].Recv != "Store" {
+		t.Errorf("LookupSymbol(Get) = %v", syms)
+	}
+	if syms, _ := idx.LookupSymbol("Put"); len(syms) != 0 {
+		t.Errorf("LookupSymbol(Put) = %v, want none", syms)
+	}
+}
+
+func TestIndexChecksum(t *testing.T) {
+	pkg, err := ParseString("store", indexSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeTestIndex(t, []*Package{pkg}, nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flip the last byte of the code section, symbols read their code
+	entry := data[indexHeaderSize+indexDirEntrySize*int(sectionCode-1):]
+	end := binary.LittleEndian.Uint64(entry[8:]) + binary.LittleEndian.Uint64(entry[16:])
+	data[end-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := OpenIndex(path)
+	if err != nil {
+		t.Fatalf("sections are checked on first use, OpenIndex failed: %v", err)
+	}
+	defer idx.Close()
+
+	if _, err := idx.Symbol(0); !errors.Is(err, ErrIndexCorrupt) {
+		t.Errorf("Symbol(0) error is %v, want ErrIndexCorrupt", err)
+	}
+	if err := idx.Verify(); !errors.Is(err, ErrIndexCorrupt) {
+		t.Errorf("Verify error is %v, want ErrIndexCorrupt", err)
+	}
+}
+
+func TestIndexVersion(t *testing.T) {
+	path := writeTestIndex(t, nil, nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(data[4:], indexVersion+1)
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenIndex(path); !errors.Is(err, ErrIndexVersion) {
+		t.Errorf("OpenIndex error is %v, want ErrIndexVersion", err)
+	}
+}
@@ -0,0 +1,461 @@
+package goretriever
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const lsifVersion = "0.4.3"
+
+type lsifPos struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lsifRange struct {
+	id  int
+	doc *lsifDocument
+}
+
+type lsifSymbol struct {
+	resultSet int
+	hover     string
+	defs      []*lsifRange
+	refs      []*lsifRange
+	impls     []*lsifRange
+}
+
+type lsifDocument struct {
+	id     int
+	ranges []int
+}
+
+type lsifWriter struct {
+	enc     *json.Encoder
+	nextID  int
+	err     error
+	fset    *token.FileSet
+	docs    map[string]*lsifDocument
+	docList []*lsifDocument
+	ranges  map[token.Position]*lsifRange
+	symbols map[string]*lsifSymbol
+	symList []*lsifSymbol
+	content map[string][]byte
+	docText map[string]string
+}
+
+func (w *lsifWriter) emit(v map[string]interface{}) int {
+	w.nextID++
+	v["id"] = w.nextID
+	if w.err == nil {
+		w.err = w.enc.Encode(v)
+	}
+	return w.nextID
+}
+
+func (w *lsifWriter) vertex(label string, fields map[string]interface{}) int {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["type"] = "vertex"
+	fields["label"] = label
+	return w.emit(fields)
+}
+
+func (w *lsifWriter) edge(label string, outV int, inV int) {
+	w.emit(map[string]interface{}{
+		"type":  "edge",
+		"label": label,
+		"outV":  outV,
+		"inV":   inV,
+	})
+}
+
+func (w *lsifWriter) edges(label string, outV int, inVs []int, fields map[string]interface{}) {
+	if len(inVs) == 0 {
+		return
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["type"] = "edge"
+	fields["label"] = label
+	fields["outV"] = outV
+	fields["inVs"] = inVs
+	w.emit(fields)
+}
+
+// objectKey identifies an object independently of the package which
+// type checked it.
+func objectKey(fset *token.FileSet, obj types.Object) string {
+	var pkg string
+	if obj.Pkg() != nil {
+		pkg = obj.Pkg().Path()
+	}
+	return pkg + "\x00" + obj.Name() + "\x00" + fset.Position(obj.Pos()).String()
+}
+
+// position converts a byte based token position into a zero based
+// utf-16 position.
+func (w *lsifWriter) position(p token.Position) lsifPos {
+	content, ok := w.content[p.Filename]
+	if !ok {
+		content, _ = os.ReadFile(p.Filename)
+		w.content[p.Filename] = content
+	}
+
+	lineStart := p.Offset - (p.Column - 1)
+	if lineStart < 0 || p.Offset > len(content) {
+		return lsifPos{Line: p.Line - 1, Character: p.Column - 1}
+	}
+
+	var character int
+	for b := content[lineStart:p.Offset]; len(b) > 0; {
+		r, size := utf8.DecodeRune(b)
+		character += utf16.RuneLen(r)
+		b = b[size:]
+	}
+	return lsifPos{Line: p.Line - 1, Character: character}
+}
+
+func (w *lsifWriter) symbol(obj types.Object, qualifier types.Qualifier) *lsifSymbol {
+	key := objectKey(w.fset, obj)
+	if s, ok := w.symbols[key]; ok {
+		return s
+	}
+
+	hover := "```go\n" + types.ObjectString(obj, qualifier) + "\n```"
+	if doc := w.docText[key]; doc != "" {
+		hover += "\n\n" + doc
+	}
+
+	s := &lsifSymbol{
+		resultSet: w.vertex("resultSet", nil),
+		hover:     hover,
+	}
+	w.symbols[key] = s
+	w.symList = append(w.symList, s)
+	return s
+}
+
+// rangeAt emits the range of ident and attaches it to the result set
+// of s. Identifiers outside of the indexed documents are ignored.
+func (w *lsifWriter) rangeAt(ident *ast.Ident, s *lsifSymbol) *lsifRange {
+	start := w.fset.Position(ident.Pos())
+	doc := w.docs[start.Filename]
+	if doc == nil {
+		return nil
+	}
+	if r, ok := w.ranges[start]; ok {
+		return r
+	}
+
+	r := &lsifRange{doc: doc}
+	r.id = w.vertex("range", map[string]interface{}{
+		"start": w.position(start),
+		"end":   w.position(w.fset.Position(ident.End())),
+	})
+	w.edge("next", r.id, s.resultSet)
+
+	w.ranges[start] = r
+	doc.ranges = append(doc.ranges, r.id)
+	return r
+}
+
+// collectDocs records the doc comments of all declarations in file.
+func (w *lsifWriter) collectDocs(pkg *packages.Package, file *ast.File) {
+	add := func(ident *ast.Ident, groups ...*ast.CommentGroup) {
+		obj := pkg.TypesInfo.Defs[ident]
+		if obj == nil {
+			return
+		}
+		for _, g := range groups {
+			if g != nil {
+				w.docText[objectKey(w.fset, obj)] = g.Text()
+				return
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncDecl:
+			add(x.Name, x.Doc)
+		case *ast.GenDecl:
+			var decl *ast.CommentGroup
+			if len(x.Specs) == 1 {
+				decl = x.Doc
+			}
+			for _, spec := range x.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, s.Doc, decl)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						add(name, s.Doc, decl)
+					}
+				}
+			}
+		case *ast.Field:
+			for _, name := range x.Names {
+				add(name, x.Doc)
+			}
+		}
+		return true
+	})
+}
+
+// collectImplementations links every non empty interface declared in
+// the indexed packages to the types and methods implementing it.
+func (w *lsifWriter) collectImplementations(named []*types.TypeName) {
+	symbolOf := func(obj types.Object) *lsifSymbol {
+		return w.symbols[objectKey(w.fset, obj)]
+	}
+
+	for _, iface := range named {
+		it, ok := iface.Type().Underlying().(*types.Interface)
+		if !ok || it.NumMethods() == 0 || !it.IsMethodSet() || isGeneric(iface) {
+			continue
+		}
+		ifaceSym := symbolOf(iface)
+
+		for _, concrete := range named {
+			if types.IsInterface(concrete.Type()) || isGeneric(concrete) {
+				continue
+			}
+
+			var recv types.Type = concrete.Type()
+			if !types.Implements(recv, it) {
+				recv = types.NewPointer(recv)
+				if !types.Implements(recv, it) {
+					continue
+				}
+			}
+
+			if cs := symbolOf(concrete); cs != nil && ifaceSym != nil {
+				ifaceSym.impls = append(ifaceSym.impls, cs.defs...)
+			}
+
+			for i := 0; i < it.NumMethods(); i++ {
+				m := it.Method(i)
+				obj, _, _ := types.LookupFieldOrMethod(recv, false, m.Pkg(), m.Name())
+				impl, ok := obj.(*types.Func)
+				if !ok {
+					continue
+				}
+				ms, cs := symbolOf(m), symbolOf(impl)
+				if ms != nil && cs != nil {
+					ms.impls = append(ms.impls, cs.defs...)
+				}
+			}
+		}
+	}
+}
+
+func isGeneric(tn *types.TypeName) bool {
+	named, ok := tn.Type().(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+// itemsByDocument emits one item edge per document for ranges.
+func (w *lsifWriter) itemsByDocument(outV int, ranges []*lsifRange, property string) {
+	var (
+		order  []*lsifDocument
+		groups = make(map[*lsifDocument][]int)
+		seen   = make(map[*lsifRange]bool)
+	)
+	for _, r := range ranges {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		if groups[r.doc] == nil {
+			order = append(order, r.doc)
+		}
+		groups[r.doc] = append(groups[r.doc], r.id)
+	}
+
+	for _, doc := range order {
+		fields := map[string]interface{}{"document": doc.id}
+		if property != "" {
+			fields["property"] = property
+		}
+		w.edges("item", outV, groups[doc], fields)
+	}
+}
+
+func fileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// WriteLSIF writes an LSIF index of pkgs with definitions, references,
+// hover documentation and implementations. All packages must share
+// the same FileSet.
+func WriteLSIF(out io.Writer, projectPath string, pkgs []*packages.Package) error {
+	if len(pkgs) == 0 {
+		return nil
+	}
+
+	w := &lsifWriter{
+		enc:     json.NewEncoder(out),
+		fset:    pkgs[0].Fset,
+		docs:    make(map[string]*lsifDocument),
+		ranges:  make(map[token.Position]*lsifRange),
+		symbols: make(map[string]*lsifSymbol),
+		content: make(map[string][]byte),
+		docText: make(map[string]string),
+	}
+
+	w.vertex("metaData", map[string]interface{}{
+		"version":          lsifVersion,
+		"projectRoot":      fileURI(projectPath),
+		"positionEncoding": "utf-16",
+		"toolInfo":         map[string]interface{}{"name": "go-retriever"},
+	})
+	project := w.vertex("project", map[string]interface{}{"kind": "go"})
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			name := w.fset.Position(file.Pos()).Filename
+			if w.docs[name] != nil {
+				continue
+			}
+			doc := &lsifDocument{}
+			doc.id = w.vertex("document", map[string]interface{}{
+				"uri":        fileURI(name),
+				"languageId": "go",
+			})
+			w.docs[name] = doc
+			w.docList = append(w.docList, doc)
+
+			w.collectDocs(pkg, file)
+		}
+	}
+	docIDs := make([]int, len(w.docList))
+	for i, doc := range w.docList {
+		docIDs[i] = doc.id
+	}
+	w.edges("contains", project, docIDs, nil)
+
+	var named []*types.TypeName
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		qualifier := types.RelativeTo(pkg.Types)
+
+		var defs, uses []*ast.Ident
+		for ident, obj := range pkg.TypesInfo.Defs {
+			if obj != nil && ident.Name != "_" {
+				defs = append(defs, ident)
+			}
+		}
+		for ident, obj := range pkg.TypesInfo.Uses {
+			if _, ok := obj.(*types.PkgName); !ok {
+				uses = append(uses, ident)
+			}
+		}
+		sort.Slice(defs, func(i, j int) bool { return defs[i].Pos() < defs[j].Pos() })
+		sort.Slice(uses, func(i, j int) bool { return uses[i].Pos() < uses[j].Pos() })
+
+		for _, ident := range defs {
+			obj := pkg.TypesInfo.Defs[ident]
+			if _, ok := obj.(*types.PkgName); ok {
+				continue
+			}
+			s := w.symbol(obj, qualifier)
+			if r := w.rangeAt(ident, s); r != nil {
+				s.defs = append(s.defs, r)
+			}
+
+			if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+				if _, ok := tn.Type().(*types.Named); ok && tn.Parent() == tn.Pkg().Scope() {
+					named = append(named, tn)
+				}
+			}
+		}
+		for _, ident := range uses {
+			obj := pkg.TypesInfo.Uses[ident]
+			if obj == nil {
+				continue
+			}
+			s := w.symbol(obj, qualifier)
+			if r := w.rangeAt(ident, s); r != nil {
+				s.refs = append(s.refs, r)
+			}
+		}
+	}
+
+	for _, doc := range w.docList {
+		sort.Ints(doc.ranges)
+		w.edges("contains", doc.id, doc.ranges, nil)
+	}
+
+	w.collectImplementations(named)
+
+	for _, s := range w.symList {
+		hover := w.vertex("hoverResult", map[string]interface{}{
+			"result": map[string]interface{}{
+				"contents": map[string]interface{}{
+					"kind":  "markdown",
+					"value": s.hover,
+				},
+			},
+		})
+		w.edge("textDocument/hover", s.resultSet, hover)
+
+		if len(s.defs) > 0 {
+			def := w.vertex("definitionResult", nil)
+			w.edge("textDocument/definition", s.resultSet, def)
+			w.itemsByDocument(def, s.defs, "")
+		}
+
+		if len(s.defs) > 0 || len(s.refs) > 0 {
+			ref := w.vertex("referenceResult", nil)
+			w.edge("textDocument/references", s.resultSet, ref)
+			w.itemsByDocument(ref, s.defs, "definitions")
+			w.itemsByDocument(ref, s.refs, "references")
+		}
+
+		if len(s.impls) > 0 {
+			impl := w.vertex("implementationResult", nil)
+			w.edge("textDocument/implementation", s.resultSet, impl)
+			w.itemsByDocument(impl, s.impls, "")
+		}
+	}
+
+	return w.err
+}
+
+// ExportLSIF loads the packages matching packagePattern and writes
+// their LSIF index.
+func ExportLSIF(out io.Writer, projectPath string, packagePattern []string, envs []string, opts ...Option) error {
+	a, err := NewAnalyzer(projectPath, packagePattern, envs, opts...)
+	if err != nil {
+		return err
+	}
+	return a.WriteLSIF(out)
+}
+
+// WriteLSIF writes the LSIF index of the loaded packages.
+func (a *Analyzer) WriteLSIF(out io.Writer) error {
+	return WriteLSIF(out, a.ProjectPath, a.Packages)
+}
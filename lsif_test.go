@@ -0,0 +1,175 @@
+package goretriever
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lsifGraph indexes the vertices and edges of an LSIF dump.
+type lsifGraph struct {
+	t        *testing.T
+	vertices map[int]map[string]interface{}
+	edges    []map[string]interface{}
+}
+
+func readLSIF(t *testing.T, data []byte) *lsifGraph {
+	g := &lsifGraph{t: t, vertices: make(map[int]map[string]interface{})}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var v map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			t.Fatalf("invalid line %s: %v", scanner.Text(), err)
+		}
+		if v["type"] == "edge" {
+			g.edges = append(g.edges, v)
+		} else {
+			g.vertices[int(v["id"].(float64))] = v
+		}
+	}
+	return g
+}
+
+func lsifIDs(v interface{}) []int {
+	var ids []int
+	switch v := v.(type) {
+	case float64:
+		ids = append(ids, int(v))
+	case []interface{}:
+		for _, id := range v {
+			ids = append(ids, int(id.(float64)))
+		}
+	}
+	return ids
+}
+
+// out returns the ids reached from outV through the edges with label.
+func (g *lsifGraph) out(outV int, label string) []int {
+	var ids []int
+	for _, e := range g.edges {
+		if e["label"] == label && int(e["outV"].(float64)) == outV {
+			ids = append(ids, lsifIDs(e["inV"])...)
+			ids = append(ids, lsifIDs(e["inVs"])...)
+		}
+	}
+	return ids
+}
+
+// rangeAt returns the range starting at line and character of the
+// document whose uri ends with file.
+func (g *lsifGraph) rangeAt(file string, line, character int) int {
+	for id, v := range g.vertices {
+		if v["label"] != "document" || !strings.HasSuffix(v["uri"].(string), file) {
+			continue
+		}
+		for _, r := range g.out(id, "contains") {
+			start := g.vertices[r]["start"].(map[string]interface{})
+			if int(start["line"].(float64)) == line && int(start["character"].(float64)) == character {
+				return r
+			}
+		}
+	}
+	g.t.Fatalf("no range at %s:%d:%d", file, line, character)
+	return 0
+}
+
+// result follows the range r to the result of its result set.
+func (g *lsifGraph) result(r int, label string) []int {
+	sets := g.out(r, "next")
+	if len(sets) != 1 {
+		g.t.Fatalf("range %d has %d result sets", r, len(sets))
+	}
+	var items []int
+	for _, res := range g.out(sets[0], label) {
+		items = append(items, g.out(res, "item")...)
+	}
+	return items
+}
+
+func containsID(ids []int, id int) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWriteLSIF(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"shape/shape.go": `package shape
+
+// Shape has an area.
+type Shape interface {
+	Area() int
+}
+
+// Square is a Shape.
+type Square struct{ Side int }
+
+func (s Square) Area() int { return s.Side * s.Side }
+`,
+		"use/use.go": `package use
+
+import "example.com/m/shape"
+
+func Total(shapes []shape.Shape) int {
+	var n int
+	for _, s := range shapes {
+		n += s.Area()
+	}
+	return n
+}
+`,
+	})
+
+	var b bytes.Buffer
+	if err := ExportLSIF(&b, dir, []string{"./..."}, nil); err != nil {
+		t.Fatal(err)
+	}
+	g := readLSIF(t, b.Bytes())
+
+	if meta := g.vertices[1]; meta["label"] != "metaData" || !strings.HasSuffix(meta["projectRoot"].(string), filepath.ToSlash(dir)) {
+		t.Errorf("first vertex is %v, want the metadata of the project", meta)
+	}
+
+	var (
+		shapeGo    = "/shape/shape.go"
+		useGo      = "/use/use.go"
+		shapeDef   = g.rangeAt(shapeGo, 3, 5)
+		areaDef    = g.rangeAt(shapeGo, 4, 1)
+		squareDef  = g.rangeAt(shapeGo, 8, 5)
+		squareArea = g.rangeAt(shapeGo, 10, 16)
+		shapeUse   = g.rangeAt(useGo, 4, 26)
+		areaCall   = g.rangeAt(useGo, 7, 9)
+	)
+
+	if defs := g.result(shapeUse, "textDocument/definition"); len(defs) != 1 || defs[0] != shapeDef {
+		t.Errorf("definition of shape.Shape is %v, want [%d]", defs, shapeDef)
+	}
+	if refs := g.result(shapeDef, "textDocument/references"); !containsID(refs, shapeUse) {
+		t.Errorf("references of Shape %v lack its use in use.go", refs)
+	}
+	if impls := g.result(shapeDef, "textDocument/implementation"); len(impls) != 1 || impls[0] != squareDef {
+		t.Errorf("implementations of Shape are %v, want [%d]", impls, squareDef)
+	}
+	if impls := g.result(areaCall, "textDocument/implementation"); len(impls) != 1 || impls[0] != squareArea {
+		t.Errorf("implementations of Shape.Area are %v, want [%d]", impls, squareArea)
+	}
+	if defs := g.result(areaCall, "textDocument/definition"); len(defs) != 1 || defs[0] != areaDef {
+		t.Errorf("definition of s.Area is %v, want [%d]", defs, areaDef)
+	}
+
+	sets := g.out(shapeDef, "next")
+	hovers := g.out(sets[0], "textDocument/hover")
+	if len(hovers) != 1 {
+		t.Fatalf("Shape has %d hover results", len(hovers))
+	}
+	hover, _ := json.Marshal(g.vertices[hovers[0]])
+	if !strings.Contains(string(hover), "Shape has an area.") {
+		t.Errorf("hover of Shape lacks its doc: %s", hover)
+	}
+}
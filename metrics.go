@@ -0,0 +1,367 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Metrics are the complexity and size metrics of a function. Closures
+// are functions of their own, their statements do not count in the
+// complexity of the enclosing function, only in its lines.
+type Metrics struct {
+	// Cyclomatic is one plus the number of decisions: if, for, range,
+	// case and comm clauses, && and ||.
+	Cyclomatic int
+	// Cognitive weighs control structures by their nesting, following
+	// the definition of SonarSource.
+	Cognitive int
+	// Nesting is the maximum nesting depth of control structures.
+	Nesting int
+	// Lines is the number of lines holding code, comments and blank
+	// lines excluded.
+	Lines  int
+	Params int
+	// FanIn and FanOut are the numbers of distinct callers and callees
+	// in the call graph, they are only set for call graph nodes.
+	FanIn  int
+	FanOut int
+}
+
+// MetricKey selects a metric.
+type MetricKey int
+
+const (
+	MetricCyclomatic MetricKey = iota
+	MetricCognitive
+	MetricNesting
+	MetricLines
+	MetricParams
+	MetricFanIn
+	MetricFanOut
+)
+
+func (k MetricKey) String() string {
+	switch k {
+	case MetricCyclomatic:
+		return "cyclomatic"
+	case MetricCognitive:
+		return "cognitive"
+	case MetricNesting:
+		return "nesting"
+	case MetricLines:
+		return "lines"
+	case MetricParams:
+		return "params"
+	case MetricFanIn:
+		return "fan-in"
+	case MetricFanOut:
+		return "fan-out"
+	}
+	return fmt.Sprintf("MetricKey(%d)", int(k))
+}
+
+// Get returns the metric selected by key.
+func (m *Metrics) Get(key MetricKey) int {
+	switch key {
+	case MetricCyclomatic:
+		return m.Cyclomatic
+	case MetricCognitive:
+		return m.Cognitive
+	case MetricNesting:
+		return m.Nesting
+	case MetricLines:
+		return m.Lines
+	case MetricParams:
+		return m.Params
+	case MetricFanIn:
+		return m.FanIn
+	case MetricFanOut:
+		return m.FanOut
+	}
+	return 0
+}
+
+// metricsVisitor computes the complexity of a function body.
+type metricsVisitor struct {
+	m *Metrics
+}
+
+// newMetrics computes the metrics of the function declared by node,
+// with the type typ and the body body.
+func newMetrics(fset *token.FileSet, node ast.Node, typ *ast.FuncType, body *ast.BlockStmt) *Metrics {
+	m := &Metrics{Cyclomatic: 1}
+
+	if typ != nil && typ.Params != nil {
+		for _, field := range typ.Params.List {
+			if len(field.Names) == 0 {
+				m.Params++
+			}
+			m.Params += len(field.Names)
+		}
+	}
+
+	lines := make(map[int]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil:
+			return false
+		case *ast.CommentGroup:
+			return false
+		}
+		lines[fset.Position(n.Pos()).Line] = true
+		lines[fset.Position(n.End()-1).Line] = true
+		return true
+	})
+	m.Lines = len(lines)
+
+	if body != nil {
+		v := &metricsVisitor{m: m}
+		v.walk(body, 0)
+	}
+	return m
+}
+
+func (v *metricsVisitor) walk(node ast.Node, nesting int) {
+	if node == nil {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		return v.node(n, nesting)
+	})
+}
+
+// enter counts a control structure at the given nesting, its body is
+// at nesting + 1.
+func (v *metricsVisitor) enter(nesting int) {
+	v.m.Cognitive += 1 + nesting
+	if nesting+1 > v.m.Nesting {
+		v.m.Nesting = nesting + 1
+	}
+}
+
+// node counts n and tells whether its children remain to be visited.
+func (v *metricsVisitor) node(n ast.Node, nesting int) bool {
+	switch n := n.(type) {
+	case *ast.FuncLit:
+		return false
+
+	case *ast.IfStmt:
+		v.ifStmt(n, nesting, false)
+		return false
+
+	case *ast.ForStmt:
+		v.m.Cyclomatic++
+		v.enter(nesting)
+		v.walk(n.Init, nesting)
+		v.walk(n.Cond, nesting)
+		v.walk(n.Post, nesting)
+		v.walk(n.Body, nesting+1)
+		return false
+
+	case *ast.RangeStmt:
+		v.m.Cyclomatic++
+		v.enter(nesting)
+		v.walk(n.X, nesting)
+		v.walk(n.Body, nesting+1)
+		return false
+
+	case *ast.SwitchStmt:
+		v.enter(nesting)
+		v.walk(n.Init, nesting)
+		v.walk(n.Tag, nesting)
+		v.walk(n.Body, nesting+1)
+		return false
+
+	case *ast.TypeSwitchStmt:
+		v.enter(nesting)
+		v.walk(n.Init, nesting)
+		v.walk(n.Assign, nesting)
+		v.walk(n.Body, nesting+1)
+		return false
+
+	case *ast.SelectStmt:
+		v.enter(nesting)
+		v.walk(n.Body, nesting+1)
+		return false
+
+	case *ast.CaseClause:
+		if n.List != nil {
+			v.m.Cyclomatic++
+		}
+	case *ast.CommClause:
+		if n.Comm != nil {
+			v.m.Cyclomatic++
+		}
+
+	case *ast.BinaryExpr:
+		if n.Op != token.LAND && n.Op != token.LOR {
+			break
+		}
+		v.m.Cyclomatic++
+		// a sequence of the same operator counts once
+		if x, ok := ast.Unparen(n.X).(*ast.BinaryExpr); !ok || x.Op != n.Op {
+			v.m.Cognitive++
+		}
+
+	case *ast.BranchStmt:
+		if n.Tok == token.GOTO || n.Label != nil {
+			v.m.Cognitive++
+		}
+	}
+	return true
+}
+
+func (v *metricsVisitor) ifStmt(n *ast.IfStmt, nesting int, elseIf bool) {
+	v.m.Cyclomatic++
+	if elseIf {
+		// else if is not nested in the if it follows
+		v.m.Cognitive++
+	} else {
+		v.enter(nesting)
+	}
+	v.walk(n.Init, nesting)
+	v.walk(n.Cond, nesting)
+	v.walk(n.Body, nesting+1)
+
+	switch e := n.Else.(type) {
+	case *ast.IfStmt:
+		v.ifStmt(e, nesting, true)
+	case *ast.BlockStmt:
+		v.m.Cognitive++
+		v.walk(e, nesting+1)
+	}
+}
+
+// updateFanMetrics sets the fan-in and fan-out of the nodes with metrics.
+func (g *CallGraph) updateFanMetrics() {
+	for _, n := range g.nodes {
+		if n.Metrics != nil {
+			n.Metrics.FanIn = len(n.In)
+			n.Metrics.FanOut = len(n.Out)
+		}
+	}
+}
+
+// PackageMetrics aggregates the metrics of the functions of a package.
+type PackageMetrics struct {
+	Pkg   string
+	Funcs int
+	// Sum is the sum of the metrics of the functions, Max their maximum.
+	Sum Metrics
+	Max Metrics
+}
+
+// Mean returns the mean of the metric selected by key.
+func (p *PackageMetrics) Mean(key MetricKey) float64 {
+	if p.Funcs == 0 {
+		return 0
+	}
+	return float64(p.Sum.Get(key)) / float64(p.Funcs)
+}
+
+// MetricsReport lists the metrics of the functions of a call graph.
+type MetricsReport struct {
+	// Funcs are the nodes with metrics, ordered by id until sorted.
+	Funcs []*CallNode
+	// Packages are ordered by path.
+	Packages []*PackageMetrics
+}
+
+// NewMetricsReport collects the metrics of the nodes of callGraph
+// declared in the loaded packages.
+func NewMetricsReport(callGraph *CallGraph) *MetricsReport {
+	var (
+		r    = &MetricsReport{}
+		pkgs = make(map[string]*PackageMetrics)
+	)
+
+	for _, n := range callGraph.Nodes() {
+		if n.Metrics == nil {
+			continue
+		}
+		r.Funcs = append(r.Funcs, n)
+
+		p, ok := pkgs[n.Pkg]
+		if !ok {
+			p = &PackageMetrics{Pkg: n.Pkg}
+			pkgs[n.Pkg] = p
+			r.Packages = append(r.Packages, p)
+		}
+		p.Funcs++
+		for key := MetricCyclomatic; key <= MetricFanOut; key++ {
+			value := n.Metrics.Get(key)
+			p.Sum.set(key, p.Sum.Get(key)+value)
+			if value > p.Max.Get(key) {
+				p.Max.set(key, value)
+			}
+		}
+	}
+
+	sort.Slice(r.Packages, func(i, j int) bool { return r.Packages[i].Pkg < r.Packages[j].Pkg })
+	return r
+}
+
+func (m *Metrics) set(key MetricKey, value int) {
+	switch key {
+	case MetricCyclomatic:
+		m.Cyclomatic = value
+	case MetricCognitive:
+		m.Cognitive = value
+	case MetricNesting:
+		m.Nesting = value
+	case MetricLines:
+		m.Lines = value
+	case MetricParams:
+		m.Params = value
+	case MetricFanIn:
+		m.FanIn = value
+	case MetricFanOut:
+		m.FanOut = value
+	}
+}
+
+// SortBy orders the functions by decreasing key, then by id.
+func (r *MetricsReport) SortBy(key MetricKey) {
+	sort.SliceStable(r.Funcs, func(i, j int) bool {
+		a, b := r.Funcs[i].Metrics.Get(key), r.Funcs[j].Metrics.Get(key)
+		if a != b {
+			return a > b
+		}
+		return r.Funcs[i].ID < r.Funcs[j].ID
+	})
+}
+
+// Top returns the first n functions, all of them when there are fewer.
+func (r *MetricsReport) Top(n int) []*CallNode {
+	if n > len(r.Funcs) {
+		n = len(r.Funcs)
+	}
+	return r.Funcs[:n]
+}
+
+// String renders the report as two tables, functions in their current
+// order then packages.
+func (r *MetricsReport) String() string {
+	b := &strings.Builder{}
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "cyclo\tcognit\tnest\tlines\tparams\tfan-in\tfan-out\tfunction")
+	for _, n := range r.Funcs {
+		m := n.Metrics
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			m.Cyclomatic, m.Cognitive, m.Nesting, m.Lines, m.Params, m.FanIn, m.FanOut, n.ID)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "funcs\tlines\tcyclo\tmean\tmax\tcognit\tmax\tpackage")
+	for _, p := range r.Packages {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.1f\t%d\t%d\t%d\t%s\n",
+			p.Funcs, p.Sum.Lines, p.Sum.Cyclomatic, p.Mean(MetricCyclomatic), p.Max.Cyclomatic,
+			p.Sum.Cognitive, p.Max.Cognitive, p.Pkg)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package goretriever
+
+import (
+	"strings"
+	"testing"
+)
+
+const metricsSource = `package calc
+
+// Classify has nested control flow.
+func Classify(xs []int, limit int) (n int) {
+	for _, x := range xs {
+		if x > limit && x%2 == 0 {
+			n++
+		} else if x < 0 {
+			n--
+		} else {
+			continue
+		}
+	}
+
+	// closures are functions of their own
+	f := func() {
+		if n > 0 {
+			n = Add(n, 1, "")
+		}
+	}
+	f()
+
+	switch {
+	case n > 10:
+		return 10
+	case n < 0 || n > 5 || n == 3:
+		return 0
+	}
+	return n
+}
+
+func Add(a, b int, _ string) int { return a + b }
+
+func Search(grid [][]int) bool {
+outer:
+	for _, row := range grid {
+		for _, v := range row {
+			if v == 0 {
+				break outer
+			}
+		}
+	}
+	return Add(1, 2, "") > 0
+}
+
+func Run() {
+	Classify(nil, Add(1, 2, ""))
+	Search(nil)
+}
+`
+
+func TestMetrics(t *testing.T) {
+	pkg, err := ParseString("calc.go", metricsSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want Metrics
+	}{
+		{"Classify", Metrics{Cyclomatic: 9, Cognitive: 8, Nesting: 2, Lines: 24, Params: 2}},
+		{"Add", Metrics{Cyclomatic: 1, Lines: 1, Params: 3}},
+		// the labeled break adds to the cognitive complexity only
+		{"Search", Metrics{Cyclomatic: 4, Cognitive: 7, Nesting: 3, Lines: 11, Params: 1}},
+		{"Run", Metrics{Cyclomatic: 1, Lines: 4}},
+	}
+	for _, test := range tests {
+		f := pkg.Functions[test.name]
+		if f == nil || f.Metrics == nil {
+			t.Errorf("no metrics for %s", test.name)
+			continue
+		}
+		if *f.Metrics != test.want {
+			t.Errorf("%s: metrics are %+v, want %+v", test.name, *f.Metrics, test.want)
+		}
+	}
+}
+
+func TestMetricsReport(t *testing.T) {
+	dir := writeModule(t, map[string]string{"calc/calc.go": metricsSource})
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := graph.Node("example.com/m/calc.Add")
+	if add.Metrics == nil || add.Metrics.FanIn != 3 || add.Metrics.FanOut != 0 {
+		t.Errorf("Add has metrics %+v, want a fan-in of 3", add.Metrics)
+	}
+	if run := graph.Node("example.com/m/calc.Run"); run.Metrics.FanOut != 3 {
+		t.Errorf("Run has a fan-out of %d, want 3", run.Metrics.FanOut)
+	}
+
+	r := NewMetricsReport(graph)
+	r.SortBy(MetricCyclomatic)
+	var top []string
+	for _, n := range r.Top(3) {
+		top = append(top, strings.TrimPrefix(n.ID, "example.com/m/calc."))
+	}
+	if got := strings.Join(top, " "); got != "Classify Search Classify$1" {
+		t.Errorf("top cyclomatic functions are %s", got)
+	}
+	if n := len(r.Top(100)); n != 5 {
+		t.Errorf("report has %d functions, want 5", n)
+	}
+
+	if len(r.Packages) != 1 {
+		t.Fatalf("report has %d packages", len(r.Packages))
+	}
+	p := r.Packages[0]
+	if p.Funcs != 5 || p.Max.Cyclomatic != 9 || p.Sum.Cyclomatic != 9+1+4+1+2 || p.Mean(MetricCyclomatic) != 17.0/5 {
+		t.Errorf("package metrics are %+v", p)
+	}
+	if s := r.String(); !strings.Contains(s, "example.com/m/calc.Classify\n") || !strings.Contains(s, "example.com/m/calc\n") {
+		t.Errorf("report is\n%s", s)
+	}
+}
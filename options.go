@@ -0,0 +1,97 @@
+package goretriever
+
+import "fmt"
+
+// ErrorPolicy tells what to do when loaded packages have errors.
+type ErrorPolicy int
+
+const (
+	// ContinueOnError carries on with whatever could be loaded, the
+	// errors are only reported.
+	ContinueOnError ErrorPolicy = iota
+	// FailOnError returns a *LoadError when a requested package has
+	// errors, the errors of dependencies are only reported.
+	FailOnError
+)
+
+// Algorithm selects how BuildCallGraph resolves the callees of calls.
+type Algorithm int
+
+const (
+	// AlgorithmAST walks the syntax trees, only static calls are resolved.
+	AlgorithmAST Algorithm = iota
+	// AlgorithmStatic keeps the static calls of the SSA form.
+	AlgorithmStatic
+	// AlgorithmCHA resolves dynamic calls by class hierarchy analysis.
+	AlgorithmCHA
+	// AlgorithmRTA resolves dynamic calls by rapid type analysis, starting
+	// from the main and init functions.
+	AlgorithmRTA
+	// AlgorithmVTA resolves dynamic calls by variable type analysis.
+	AlgorithmVTA
+)
+
+func (alg Algorithm) String() string {
+	switch alg {
+	case AlgorithmAST:
+		return "ast"
+	case AlgorithmStatic:
+		return "static"
+	case AlgorithmCHA:
+		return "cha"
+	case AlgorithmRTA:
+		return "rta"
+	case AlgorithmVTA:
+		return "vta"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(alg))
+}
+
+type options struct {
+	errorPolicy ErrorPolicy
+	diagnostics *Diagnostics
+	algorithm   Algorithm
+	tests       bool
+}
+
+// Option configures the loading and analysis functions.
+type Option func(*options)
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithErrorPolicy sets the policy for package errors, ContinueOnError
+// by default so packages with errors still give partial results.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *options) {
+		o.errorPolicy = policy
+	}
+}
+
+// WithDiagnostics fills d with the errors of the loaded packages,
+// whatever the error policy is.
+func WithDiagnostics(d *Diagnostics) Option {
+	return func(o *options) {
+		o.diagnostics = d
+	}
+}
+
+// WithAlgorithm sets the call graph algorithm, AlgorithmAST by default.
+// The SSA algorithms load and type check the dependencies from source too.
+func WithAlgorithm(alg Algorithm) Option {
+	return func(o *options) {
+		o.algorithm = alg
+	}
+}
+
+// WithTests loads the test files of the packages too.
+func WithTests() Option {
+	return func(o *options) {
+		o.tests = true
+	}
+}
@@ -7,10 +7,15 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"sort"
+	"strconv"
 )
 
 type Package struct {
 	Name      string
+	Path      string
+	Dir       string
+	Imports   []string
 	Structs   map[string]*Struct
 	Functions map[string]*Function
 }
@@ -42,6 +47,25 @@ func (p *Package) AddMethod(structName string, f *Function) {
 	s.AddMethod(f)
 }
 
+func (p *Package) ParseImports(f *ast.File) error {
+
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return err
+		}
+
+		i := sort.SearchStrings(p.Imports, path)
+		if i < len(p.Imports) && p.Imports[i] == path {
+			continue
+		}
+		p.Imports = append(p.Imports, "")
+		copy(p.Imports[i+1:], p.Imports[i:])
+		p.Imports[i] = path
+	}
+	return nil
+}
+
 func (p *Package) ParseStruct(reader io.ReaderAt, f *ast.File, fileSet *token.FileSet) error {
 
 	for _, decl := range f.Decls {
@@ -83,7 +107,12 @@ func (p *Package) ParseFunction(reader io.ReaderAt, f *ast.File, fileSet *token.
 func (p *Package) FromString(content string, f *ast.File, fileSet *token.FileSet) error {
 	reader := bytes.NewReader([]byte(content))
 
-	err := p.ParseStruct(reader, f, fileSet)
+	err := p.ParseImports(f)
+	if err != nil {
+		return err
+	}
+
+	err = p.ParseStruct(reader, f, fileSet)
 	if err != nil {
 		return err
 	}
@@ -104,6 +133,11 @@ func (p *Package) FromFile(path string, f *ast.File, fileSet *token.FileSet) err
 	}
 	defer reader.Close()
 
+	err = p.ParseImports(f)
+	if err != nil {
+		return err
+	}
+
 	err = p.ParseStruct(reader, f, fileSet)
 	if err != nil {
 		return err
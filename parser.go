@@ -6,12 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
 func ParseString(name, content string) (*Package, error) {
 	fSet := token.NewFileSet()
 
-	f, err := parser.ParseFile(fSet, name, content, 0)
+	f, err := parser.ParseFile(fSet, name, content, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -26,9 +28,44 @@ func ParseString(name, content string) (*Package, error) {
 	return pkg, nil
 }
 
+// importPath guesses the import path of the package in dir from the
+// closest go.mod file. modules caches go.mod lookups by directory.
+func importPath(dir string, modules map[string]string) string {
+	var (
+		rel     []string
+		current = dir
+	)
+	if abs, err := filepath.Abs(dir); err == nil {
+		current = abs
+	}
+	for {
+		modPath, ok := modules[current]
+		if !ok {
+			if content, err := os.ReadFile(filepath.Join(current, "go.mod")); err == nil {
+				modPath = modfile.ModulePath(content)
+			}
+			modules[current] = modPath
+		}
+		if modPath != "" {
+			for i := len(rel) - 1; i >= 0; i-- {
+				modPath += "/" + rel[i]
+			}
+			return modPath
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		rel = append(rel, filepath.Base(current))
+		current = parent
+	}
+}
+
 func Parse(dir string) []*Package {
 
 	var structedPkgs []*Package
+	var modules = make(map[string]string)
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -50,12 +87,15 @@ func Parse(dir string) []*Package {
 			for _, pkg := range pkgs {
 
 				tmp := NewPackage(pkg.Name)
+				tmp.Dir = path
+				tmp.Path = importPath(path, modules)
 				for path, f := range pkg.Files {
 					file, err := os.OpenFile(path, os.O_RDONLY, os.ModePerm)
 					if err != nil {
 						return err
 					}
 
+					tmp.ParseImports(f)
 					tmp.ParseStruct(file, f, fs)
 
 					file.Close()
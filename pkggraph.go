@@ -0,0 +1,101 @@
+package goretriever
+
+import (
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// PackageImport is the import of a package by another one.
+type PackageImport struct {
+	From string
+	To   string
+	// Positions are the import specs, one per importing file.
+	Positions []token.Position
+}
+
+// PackageGraph is the import graph of the loaded packages.
+type PackageGraph struct {
+	// Packages are the paths of the loaded packages, ordered.
+	Packages []string
+	// Imports are ordered by importing and imported package.
+	Imports []*PackageImport
+}
+
+// ImportsOf returns the imports of the package with the given path.
+func (g *PackageGraph) ImportsOf(path string) []*PackageImport {
+	var imports []*PackageImport
+	for _, imp := range g.Imports {
+		if imp.From == path {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
+// ImportersOf returns the imports of the package with the given path
+// by the loaded packages.
+func (g *PackageGraph) ImportersOf(path string) []*PackageImport {
+	var imports []*PackageImport
+	for _, imp := range g.Imports {
+		if imp.To == path {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
+// PackageGraph returns the import graph of the loaded packages. Imported
+// packages which are not loaded are in the imports but not in Packages.
+func (a *Analyzer) PackageGraph() *PackageGraph {
+	var (
+		g       = &PackageGraph{}
+		seen    = make(map[string]bool)
+		imports = make(map[[2]string]*PackageImport)
+	)
+
+	for _, pkg := range a.Packages {
+		if !seen[pkg.PkgPath] {
+			seen[pkg.PkgPath] = true
+			g.Packages = append(g.Packages, pkg.PkgPath)
+		}
+
+		for _, file := range pkg.Syntax {
+			for _, spec := range file.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					continue
+				}
+				// the import path as written may be vendored
+				if imported, ok := pkg.Imports[path]; ok {
+					path = imported.PkgPath
+				}
+
+				key := [2]string{pkg.PkgPath, path}
+				imp, ok := imports[key]
+				if !ok {
+					imp = &PackageImport{From: pkg.PkgPath, To: path}
+					imports[key] = imp
+					g.Imports = append(g.Imports, imp)
+				}
+				pos := a.Fset.Position(spec.Pos())
+				var dup bool
+				for _, p := range imp.Positions {
+					dup = dup || p == pos
+				}
+				if !dup {
+					imp.Positions = append(imp.Positions, pos)
+				}
+			}
+		}
+	}
+
+	sort.Strings(g.Packages)
+	sort.Slice(g.Imports, func(i, j int) bool {
+		if g.Imports[i].From != g.Imports[j].From {
+			return g.Imports[i].From < g.Imports[j].From
+		}
+		return g.Imports[i].To < g.Imports[j].To
+	})
+	return g
+}
@@ -0,0 +1,291 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// QueryFilter restricts the nodes traversed by the call graph queries.
+// The nodes the queries start from are never filtered out.
+type QueryFilter struct {
+	// Packages lists the package path prefixes to keep, all packages
+	// are kept when empty.
+	Packages []string
+	// ExportedOnly keeps exported functions and methods only.
+	ExportedOnly bool
+	// ExcludeStd drops the functions of the standard library.
+	ExcludeStd bool
+}
+
+// isStdPackage reports whether path looks like a standard library
+// package, ie. its first element has no dot.
+func isStdPackage(path string) bool {
+	if path == "" {
+		return false
+	}
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func (f *QueryFilter) match(n *CallNode) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Packages) > 0 {
+		var ok bool
+		for _, prefix := range f.Packages {
+			prefix = strings.TrimSuffix(prefix, "/")
+			if n.Pkg == prefix || strings.HasPrefix(n.Pkg, prefix+"/") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.ExportedOnly && (n.Parent != nil || !token.IsExported(n.Name)) {
+		return false
+	}
+	// functions declared in the loaded packages are never std
+	if f.ExcludeStd && !n.Pos.IsValid() && isStdPackage(n.Pkg) {
+		return false
+	}
+	return true
+}
+
+// Reached is a node found by a query.
+type Reached struct {
+	Node *CallNode
+	// Depth is the number of calls from the closest start node.
+	Depth int
+	// Via is the edge the node was first reached through, its call
+	// sites tell where the call is made.
+	Via *CallEdge
+}
+
+// CallPath is a chain of calls, each edge calling the next one.
+type CallPath []*CallEdge
+
+// Nodes returns the functions of the path, from the first caller to
+// the last callee.
+func (p CallPath) Nodes() []*CallNode {
+	if len(p) == 0 {
+		return nil
+	}
+	nodes := []*CallNode{p[0].Caller}
+	for _, e := range p {
+		nodes = append(nodes, e.Callee)
+	}
+	return nodes
+}
+
+// String renders the path on one line per call, with the position of
+// the first call site when known.
+func (p CallPath) String() string {
+	b := &strings.Builder{}
+	for _, e := range p {
+		fmt.Fprintf(b, "%s -> %s", e.Caller.ID, e.Callee.ID)
+		if len(e.Sites) > 0 {
+			fmt.Fprintf(b, " (%s)", e.Sites[0].Pos)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// direction walks the graph forwards, to the callees, or backwards.
+type direction bool
+
+const (
+	forward  direction = true
+	backward direction = false
+)
+
+// edges returns the edges leaving n in the direction, with the node at
+// their other end, ordered by id.
+func (d direction) edges(n *CallNode) ([]*CallEdge, []*CallNode) {
+	edges := n.In
+	if d == forward {
+		edges = n.Out
+	}
+	edges = append([]*CallEdge(nil), edges...)
+
+	far := func(e *CallEdge) *CallNode {
+		if d == forward {
+			return e.Callee
+		}
+		return e.Caller
+	}
+	sort.SliceStable(edges, func(i, j int) bool { return far(edges[i]).ID < far(edges[j]).ID })
+
+	nodes := make([]*CallNode, len(edges))
+	for i, e := range edges {
+		nodes[i] = far(e)
+	}
+	return edges, nodes
+}
+
+// lookup returns the nodes with the given ids, skipping unknown ones.
+func (g *CallGraph) lookup(ids []string) []*CallNode {
+	var nodes []*CallNode
+	for _, id := range ids {
+		if n := g.Node(id); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// bfs returns the nodes reached from starts in dir, up to depth calls
+// away, 0 meaning no limit. The start nodes are not returned.
+func bfs(starts []*CallNode, dir direction, depth int, filter *QueryFilter) []*Reached {
+	var (
+		reached []*Reached
+		seen    = make(map[*CallNode]bool)
+		queue   = starts
+	)
+	for _, n := range starts {
+		seen[n] = true
+	}
+
+	for d := 1; len(queue) > 0 && (depth <= 0 || d <= depth); d++ {
+		var next []*CallNode
+		for _, n := range queue {
+			edges, nodes := dir.edges(n)
+			for i, far := range nodes {
+				if seen[far] || !filter.match(far) {
+					continue
+				}
+				seen[far] = true
+				reached = append(reached, &Reached{Node: far, Depth: d, Via: edges[i]})
+				next = append(next, far)
+			}
+		}
+		queue = next
+	}
+
+	sort.SliceStable(reached, func(i, j int) bool {
+		if reached[i].Depth != reached[j].Depth {
+			return reached[i].Depth < reached[j].Depth
+		}
+		return reached[i].Node.ID < reached[j].Node.ID
+	})
+	return reached
+}
+
+// CalleesOf returns the functions called by id, directly or not, up to
+// depth calls away, 0 meaning no limit.
+func (g *CallGraph) CalleesOf(id string, depth int, filter *QueryFilter) []*Reached {
+	return bfs(g.lookup([]string{id}), forward, depth, filter)
+}
+
+// CallersOf returns the functions calling id, directly or not, up to
+// depth calls away, 0 meaning no limit.
+func (g *CallGraph) CallersOf(id string, depth int, filter *QueryFilter) []*Reached {
+	return bfs(g.lookup([]string{id}), backward, depth, filter)
+}
+
+// Reachable returns the functions reachable from the roots, including
+// the roots themselves at depth 0. Unknown roots are ignored.
+func (g *CallGraph) Reachable(roots []string, filter *QueryFilter) []*Reached {
+	starts := g.lookup(roots)
+
+	var reached []*Reached
+	seen := make(map[*CallNode]bool)
+	for _, n := range starts {
+		if !seen[n] {
+			seen[n] = true
+			reached = append(reached, &Reached{Node: n})
+		}
+	}
+	sort.Slice(reached, func(i, j int) bool { return reached[i].Node.ID < reached[j].Node.ID })
+	return append(reached, bfs(starts, forward, 0, filter)...)
+}
+
+// ShortestPath returns one of the shortest paths of calls from the
+// function from to the function to, or nil if there is none.
+func (g *CallGraph) ShortestPath(from, to string, filter *QueryFilter) CallPath {
+	start, end := g.Node(from), g.Node(to)
+	if start == nil || end == nil || start == end {
+		return nil
+	}
+
+	via := map[*CallNode]*CallEdge{start: nil}
+	queue := []*CallNode{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		edges, nodes := forward.edges(n)
+		for i, far := range nodes {
+			if _, ok := via[far]; ok || (far != end && !filter.match(far)) {
+				continue
+			}
+			via[far] = edges[i]
+			if far != end {
+				queue = append(queue, far)
+				continue
+			}
+
+			var path CallPath
+			for e := via[end]; e != nil; e = via[e.Caller] {
+				path = append(path, e)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+	}
+	return nil
+}
+
+// AllPaths returns the simple paths of calls from the function from to
+// the function to, with at most maxDepth calls, 0 meaning no limit.
+// The number of paths grows exponentially with the size of the graph,
+// maxDepth should be set on large graphs. Paths are ordered by length.
+func (g *CallGraph) AllPaths(from, to string, maxDepth int, filter *QueryFilter) []CallPath {
+	start, end := g.Node(from), g.Node(to)
+	if start == nil || end == nil || start == end {
+		return nil
+	}
+
+	var (
+		paths  []CallPath
+		path   CallPath
+		onPath = map[*CallNode]bool{start: true}
+		visit  func(n *CallNode)
+	)
+	visit = func(n *CallNode) {
+		if maxDepth > 0 && len(path) >= maxDepth {
+			return
+		}
+		edges, nodes := forward.edges(n)
+		for i, far := range nodes {
+			if onPath[far] {
+				continue
+			}
+			if far == end {
+				paths = append(paths, append(CallPath(nil), append(path, edges[i])...))
+				continue
+			}
+			if !filter.match(far) {
+				continue
+			}
+
+			onPath[far] = true
+			path = append(path, edges[i])
+			visit(far)
+			path = path[:len(path)-1]
+			onPath[far] = false
+		}
+	}
+	visit(start)
+
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+	return paths
+}
@@ -0,0 +1,145 @@
+package goretriever
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// queryTestGraph builds main -> Serve -> handle, Get, with handle
+// calling Get and strings.Cut, Get calling load and load calling
+// Serve back.
+func queryTestGraph() *CallGraph {
+	g := NewCallGraph()
+	line := 0
+	node := func(pkg, name string) *CallNode {
+		n := g.AddNode(pkg + "." + name)
+		n.Pkg, n.Name = pkg, name
+		if pkg != "strings" {
+			line++
+			n.Pos = token.Position{Filename: pkg + ".go", Line: line, Column: 1}
+		}
+		return n
+	}
+	var (
+		main   = node("example.com/app", "main")
+		serve  = node("example.com/app", "Serve")
+		handle = node("example.com/app", "handle")
+		get    = node("example.com/store", "Get")
+		load   = node("example.com/store", "load")
+		cut    = node("strings", "Cut")
+	)
+	g.AddEdge(main, serve).addSite(&CallSite{Pos: token.Position{Filename: "main.go", Line: 7, Column: 7}})
+	g.AddEdge(serve, handle)
+	g.AddEdge(serve, get)
+	g.AddEdge(handle, get)
+	g.AddEdge(handle, cut)
+	g.AddEdge(get, load)
+	g.AddEdge(load, serve)
+	return g
+}
+
+func reachedIDs(reached []*Reached) string {
+	var ids []string
+	for _, r := range reached {
+		id := strings.TrimPrefix(r.Node.ID, "example.com/")
+		ids = append(ids, id+":"+string(rune('0'+r.Depth)))
+	}
+	return strings.Join(ids, " ")
+}
+
+func pathIDs(path CallPath) string {
+	var ids []string
+	for _, n := range path.Nodes() {
+		ids = append(ids, strings.TrimPrefix(n.ID, "example.com/"))
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestCalleesAndCallersOf(t *testing.T) {
+	g := queryTestGraph()
+
+	tests := []struct {
+		name   string
+		got    []*Reached
+		expect string
+	}{
+		{"depth 1", g.CalleesOf("example.com/app.main", 1, nil),
+			"app.Serve:1"},
+		{"no depth limit", g.CalleesOf("example.com/app.main", 0, nil),
+			"app.Serve:1 app.handle:2 store.Get:2 store.load:3 strings.Cut:3"},
+		{"exclude std", g.CalleesOf("example.com/app.main", 0, &QueryFilter{ExcludeStd: true}),
+			"app.Serve:1 app.handle:2 store.Get:2 store.load:3"},
+		{"exported only", g.CalleesOf("example.com/app.main", 0, &QueryFilter{ExportedOnly: true}),
+			"app.Serve:1 store.Get:2"},
+		// the start node is never filtered out
+		{"packages", g.CalleesOf("example.com/app.Serve", 0, &QueryFilter{Packages: []string{"example.com/store/"}}),
+			"store.Get:1 store.load:2"},
+		{"callers", g.CallersOf("example.com/store.Get", 1, nil),
+			"app.Serve:1 app.handle:1"},
+		{"callers through the cycle", g.CallersOf("example.com/app.handle", 0, nil),
+			"app.Serve:1 app.main:2 store.load:2 store.Get:3"},
+		{"unknown", g.CalleesOf("example.com/app.missing", 0, nil), ""},
+	}
+	for _, test := range tests {
+		if got := reachedIDs(test.got); got != test.expect {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.expect)
+		}
+	}
+
+	callers := g.CallersOf("example.com/app.Serve", 1, nil)
+	if len(callers) == 0 || callers[0].Via.Caller != callers[0].Node || callers[0].Via.Callee.ID != "example.com/app.Serve" {
+		t.Errorf("Via of %s is not the edge it was reached through", callers[0].Node.ID)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	g := queryTestGraph()
+
+	got := reachedIDs(g.Reachable([]string{"example.com/store.load", "example.com/app.missing"}, nil))
+	expect := "store.load:0 app.Serve:1 app.handle:2 store.Get:2 strings.Cut:3"
+	if got != expect {
+		t.Errorf("got %q, want %q", got, expect)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g := queryTestGraph()
+
+	path := g.ShortestPath("example.com/app.main", "example.com/store.load", nil)
+	if got, expect := pathIDs(path), "app.main app.Serve store.Get store.load"; got != expect {
+		t.Errorf("got %q, want %q", got, expect)
+	}
+	if s := path.String(); !strings.HasPrefix(s, "example.com/app.main -> example.com/app.Serve (main.go:7:7)\n") {
+		t.Errorf("path renders as %q", s)
+	}
+
+	// store.Get is on every path, the end node is never filtered out
+	if path := g.ShortestPath("example.com/app.main", "example.com/store.load",
+		&QueryFilter{Packages: []string{"example.com/app"}}); path != nil {
+		t.Errorf("got %q through a filtered out node", pathIDs(path))
+	}
+	if path := g.ShortestPath("example.com/store.load", "example.com/app.main", nil); path != nil {
+		t.Errorf("got %q, main has no callers", pathIDs(path))
+	}
+}
+
+func TestAllPaths(t *testing.T) {
+	g := queryTestGraph()
+
+	var got []string
+	for _, path := range g.AllPaths("example.com/app.main", "example.com/store.load", 0, nil) {
+		got = append(got, pathIDs(path))
+	}
+	expect := []string{
+		"app.main app.Serve store.Get store.load",
+		"app.main app.Serve app.handle store.Get store.load",
+	}
+	if strings.Join(got, ", ") != strings.Join(expect, ", ") {
+		t.Errorf("got %q, want %q", got, expect)
+	}
+
+	if paths := g.AllPaths("example.com/app.main", "example.com/store.load", 3, nil); len(paths) != 1 {
+		t.Errorf("got %d paths of at most 3 calls, want 1", len(paths))
+	}
+}
@@ -0,0 +1,342 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// RefKind tells how a symbol is referenced.
+type RefKind int
+
+const (
+	// RefRead reads a variable, a constant or a field.
+	RefRead RefKind = iota
+	// RefWrite assigns a variable or a field.
+	RefWrite
+	// RefCall calls a function or a method.
+	RefCall
+	// RefFuncValue uses a function or a method as a value.
+	RefFuncValue
+	// RefType uses a type in a type position: declarations, signatures,
+	// embedded fields, type assertions and type arguments.
+	RefType
+	// RefConversion converts a value to a type.
+	RefConversion
+	// RefCompositeLit is the type of a composite literal, or a field
+	// key of one.
+	RefCompositeLit
+)
+
+func (k RefKind) String() string {
+	switch k {
+	case RefRead:
+		return "read"
+	case RefWrite:
+		return "write"
+	case RefCall:
+		return "call"
+	case RefFuncValue:
+		return "func value"
+	case RefType:
+		return "type"
+	case RefConversion:
+		return "conversion"
+	case RefCompositeLit:
+		return "composite literal"
+	}
+	return fmt.Sprintf("RefKind(%d)", int(k))
+}
+
+// Reference is a use of a symbol.
+type Reference struct {
+	Kind RefKind
+	// Func is the id of the enclosing function as in the call graph,
+	// "" in type and constant declarations.
+	Func string
+	Pos  token.Position
+}
+
+// RefGroup are the references made by a function.
+type RefGroup struct {
+	Func string
+	Refs []*Reference
+}
+
+// SymbolRefs lists the references to a symbol.
+type SymbolRefs struct {
+	// ID is the package path and the name of the symbol, its type for
+	// fields, as in example.com/shop.Order.Status; functions and methods
+	// have the id of the call graph.
+	ID   string
+	Kind SymbolKind
+	Name string
+	// Pos is the declaration of the symbol.
+	Pos token.Position
+	// Refs are ordered by position.
+	Refs []*Reference
+}
+
+// Groups returns the references grouped by enclosing function, ordered
+// by function id.
+func (s *SymbolRefs) Groups() []*RefGroup {
+	var (
+		groups []*RefGroup
+		byFunc = make(map[string]*RefGroup)
+	)
+	for _, ref := range s.Refs {
+		g, ok := byFunc[ref.Func]
+		if !ok {
+			g = &RefGroup{Func: ref.Func}
+			byFunc[ref.Func] = g
+			groups = append(groups, g)
+		}
+		g.Refs = append(g.Refs, ref)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Func < groups[j].Func })
+	return groups
+}
+
+// ReferenceIndex maps the package level symbols, fields and methods to
+// their references in the loaded packages. Local variables are not
+// indexed.
+type ReferenceIndex struct {
+	// Symbols are ordered by id.
+	Symbols []*SymbolRefs
+
+	byID map[string]*SymbolRefs
+}
+
+// Lookup returns the references to the symbol id, or nil.
+func (ix *ReferenceIndex) Lookup(id string) *SymbolRefs {
+	return ix.byID[id]
+}
+
+// Find returns the symbols whose id is name or ends with .name, so that
+// Order.Status finds example.com/shop.Order.Status.
+func (ix *ReferenceIndex) Find(name string) []*SymbolRefs {
+	var symbols []*SymbolRefs
+	for _, s := range ix.Symbols {
+		if s.ID == name || strings.HasSuffix(s.ID, "."+name) {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// refSymbol returns the id and the kind of obj, or "" when obj is not
+// indexed. owners maps fields to the id of their struct.
+func refSymbol(obj types.Object, owners map[*types.Var]string) (string, SymbolKind) {
+	if obj.Pkg() == nil {
+		// builtin
+		return "", 0
+	}
+	global := obj.Parent() == obj.Pkg().Scope()
+
+	switch obj := obj.(type) {
+	case *types.Func:
+		if obj.Type().(*types.Signature).Recv() != nil {
+			return FuncID(obj), SymbolMethod
+		}
+		return FuncID(obj), SymbolFunction
+	case *types.TypeName:
+		if global {
+			return typeID(obj.Type()), SymbolType
+		}
+	case *types.Const:
+		if global {
+			return obj.Pkg().Path() + "." + obj.Name(), SymbolConst
+		}
+	case *types.Var:
+		if obj.IsField() {
+			if owner, ok := owners[obj.Origin()]; ok {
+				return owner + "." + obj.Name(), SymbolField
+			}
+			return "", 0
+		}
+		if global {
+			return obj.Pkg().Path() + "." + obj.Name(), SymbolVar
+		}
+	}
+	return "", 0
+}
+
+// refKind tells how ident, referring to obj, is used from its ancestors.
+func refKind(info *types.Info, ident *ast.Ident, obj types.Object, stack []ast.Node) RefKind {
+	// expr is ident or the selector of which it is the selected name
+	var expr ast.Expr = ident
+	i := len(stack) - 1
+	if i >= 0 {
+		if sel, ok := stack[i].(*ast.SelectorExpr); ok && sel.Sel == ident {
+			expr = sel
+			i--
+		}
+	}
+	var parent, grandParent ast.Node
+	for ; i >= 0; i-- {
+		if paren, ok := stack[i].(*ast.ParenExpr); ok {
+			expr = paren
+			continue
+		}
+		parent = stack[i]
+		if i > 0 {
+			grandParent = stack[i-1]
+		}
+		break
+	}
+
+	switch obj.(type) {
+	case *types.Func:
+		if call, ok := parent.(*ast.CallExpr); ok && call.Fun == expr {
+			return RefCall
+		}
+		return RefFuncValue
+
+	case *types.TypeName:
+		switch parent := parent.(type) {
+		case *ast.CallExpr:
+			if parent.Fun == expr {
+				return RefConversion
+			}
+		case *ast.CompositeLit:
+			if parent.Type == expr {
+				return RefCompositeLit
+			}
+		}
+		return RefType
+
+	case *types.Var:
+		switch parent := parent.(type) {
+		case *ast.KeyValueExpr:
+			if _, ok := grandParent.(*ast.CompositeLit); ok && parent.Key == expr {
+				return RefCompositeLit
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range parent.Lhs {
+				if lhs == expr {
+					return RefWrite
+				}
+			}
+		case *ast.IncDecStmt:
+			return RefWrite
+		case *ast.RangeStmt:
+			if parent.Key == expr || parent.Value == expr {
+				return RefWrite
+			}
+		}
+	}
+	return RefRead
+}
+
+// ReferenceIndex indexes the references to types, fields, constants,
+// package level variables, functions and methods made by the loaded
+// packages, in declarations, signatures and function bodies.
+func (a *Analyzer) ReferenceIndex() *ReferenceIndex {
+	var (
+		ix     = &ReferenceIndex{byID: make(map[string]*SymbolRefs)}
+		owners = make(map[*types.Var]string)
+	)
+
+	// fields are named after the type declaring them, or after the
+	// selections and literals using them for the struct types which are
+	// not declared by the loaded packages
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok || spec.Assign.IsValid() {
+					return true
+				}
+				if _, ok := spec.Type.(*ast.StructType); !ok {
+					return true
+				}
+				tn, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
+				if !ok {
+					return true
+				}
+				st := tn.Type().Underlying().(*types.Struct)
+				for i := 0; i < st.NumFields(); i++ {
+					owners[st.Field(i)] = typeID(tn.Type())
+				}
+				return true
+			})
+		}
+	}
+
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+		w := newFuncWalker(pkg)
+
+		for _, file := range pkg.Syntax {
+			w.walkFile(file, func(n ast.Node, stack []ast.Node, fn string) {
+				switch n := n.(type) {
+				case *ast.SelectorExpr:
+					if sel, ok := info.Selections[n]; ok && sel.Kind() == types.FieldVal {
+						field := sel.Obj().(*types.Var).Origin()
+						if _, ok := owners[field]; !ok {
+							owners[field] = typeID(fieldOwner(sel))
+						}
+					}
+
+				case *ast.CompositeLit:
+					t := info.TypeOf(n)
+					if t == nil {
+						return
+					}
+					if ptr, ok := t.Underlying().(*types.Pointer); ok {
+						t = ptr.Elem()
+					}
+					if st, ok := t.Underlying().(*types.Struct); ok {
+						for i := 0; i < st.NumFields(); i++ {
+							if _, ok := owners[st.Field(i).Origin()]; !ok {
+								owners[st.Field(i).Origin()] = typeID(t)
+							}
+						}
+					}
+
+				case *ast.Ident:
+					obj := info.Uses[n]
+					if obj == nil {
+						return
+					}
+					id, kind := refSymbol(obj, owners)
+					if id == "" {
+						return
+					}
+
+					s, ok := ix.byID[id]
+					if !ok {
+						s = &SymbolRefs{
+							ID:   id,
+							Kind: kind,
+							Name: obj.Name(),
+							Pos:  a.Fset.Position(obj.Pos()),
+						}
+						ix.byID[id] = s
+						ix.Symbols = append(ix.Symbols, s)
+					}
+					s.Refs = append(s.Refs, &Reference{
+						Kind: refKind(info, n, obj, stack),
+						Func: fn,
+						Pos:  a.Fset.Position(n.Pos()),
+					})
+				}
+			})
+		}
+	}
+
+	sort.Slice(ix.Symbols, func(i, j int) bool { return ix.Symbols[i].ID < ix.Symbols[j].ID })
+	for _, s := range ix.Symbols {
+		sort.SliceStable(s.Refs, func(i, j int) bool { return positionLess(s.Refs[i].Pos, s.Refs[j].Pos) })
+	}
+	return ix
+}
@@ -0,0 +1,112 @@
+package goretriever
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReferenceIndex(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"model/model.go": `package model
+
+type ID int
+
+const MaxID ID = 100
+
+var Default = Order{ID: 1}
+
+type Order struct {
+	ID     ID
+	Status string
+}
+
+func (o *Order) Valid() bool { return o.ID < MaxID }
+
+type Validator func(*Order) bool
+`,
+		"app/app.go": `package app
+
+import "example.com/m/model"
+
+var checks = []model.Validator{(*model.Order).Valid}
+
+func Parse(n int) model.ID {
+	return model.ID(n)
+}
+
+func Make() *model.Order {
+	o := &model.Order{Status: "new"}
+	o.ID = Parse(1)
+	if o.Valid() {
+		model.Default.Status = o.Status
+	}
+	var x interface{} = o
+	_ = x.(*model.Order)
+	return o
+}
+`,
+	})
+
+	// model is imported, it is loaded from source by the SSA algorithms
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil, WithAlgorithm(AlgorithmStatic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := a.ReferenceIndex()
+
+	refs := func(id string) string {
+		sym := ix.Lookup(id)
+		if sym == nil {
+			return "<nil>"
+		}
+		var list []string
+		for _, ref := range sym.Refs {
+			list = append(list, fmt.Sprintf("%s:%d:%s", filepath.Base(ref.Pos.Filename), ref.Pos.Line, ref.Kind))
+		}
+		return strings.Join(list, " ")
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		// the declarations of constants and fields have no function
+		{"example.com/m/model.ID", "app.go:7:type app.go:8:conversion model.go:5:type model.go:10:type"},
+		{"example.com/m/model.Order",
+			"app.go:5:type app.go:11:type app.go:12:composite literal app.go:18:type " +
+				"model.go:7:composite literal model.go:14:type model.go:16:type"},
+		{"example.com/m/model.Order.ID", "app.go:13:write model.go:7:composite literal model.go:14:read"},
+		{"example.com/m/model.Order.Status", "app.go:12:composite literal app.go:15:write app.go:15:read"},
+		{"example.com/m/model.MaxID", "model.go:14:read"},
+		{"example.com/m/model.Default", "app.go:15:read"},
+		{"(*example.com/m/model.Order).Valid", "app.go:5:func value app.go:14:call"},
+		{"example.com/m/app.Parse", "app.go:13:call"},
+	}
+	for _, test := range tests {
+		if got := refs(test.id); got != test.want {
+			t.Errorf("%s: references are\n%s\nwant\n%s", test.id, got, test.want)
+		}
+	}
+
+	var groups []string
+	for _, g := range ix.Lookup("example.com/m/model.Order").Groups() {
+		groups = append(groups, fmt.Sprintf("%s:%d", g.Func, len(g.Refs)))
+	}
+	want := []string{
+		":1",
+		"(*example.com/m/model.Order).Valid:1",
+		"example.com/m/app.Make:3",
+		"example.com/m/app.init:1",
+		"example.com/m/model.init:1",
+	}
+	if strings.Join(groups, " ") != strings.Join(want, " ") {
+		t.Errorf("groups are %v, want %v", groups, want)
+	}
+
+	found := ix.Find("Order.Status")
+	if len(found) != 1 || found[0].Kind != SymbolField || found[0].Pos.Line != 11 {
+		t.Errorf("Order.Status finds %v", found)
+	}
+}
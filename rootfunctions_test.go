@@ -0,0 +1,117 @@
+package goretriever
+
+import (
+	"go/types"
+	"sort"
+	"testing"
+)
+
+func TestRootFunctions(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"trace/trace.go": `package trace
+
+type Tracer interface{ Start(name string) }
+
+// Timer has a Start method but is not a Tracer.
+type Timer struct{}
+
+func (Timer) Start(name string) {}
+
+func Start() {}
+
+func Begin() { Start() }
+
+func Map[T any](x T) T { return x }
+`,
+		"app/app.go": `package app
+
+import "example.com/m/trace"
+
+var started = run(nil)
+
+type Server struct{ tracer trace.Tracer }
+
+func (s *Server) Handle() { s.tracer.Start("handle") }
+
+func Spawn(tr trace.Tracer) {
+	go func() { tr.Start("spawn") }()
+}
+
+func Time(t trace.Timer) { t.Start("time") }
+
+func Convert() int { return trace.Map(1) }
+
+func run(tr trace.Tracer) int { return 0 }
+`,
+	})
+
+	// trace is imported, it is loaded from source by the SSA algorithms
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil, WithAlgorithm(AlgorithmStatic))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		label string
+		want  []string
+	}{
+		{"example.com/m/trace.Tracer.Start", []string{
+			"(*example.com/m/app.Server).Handle",
+			"example.com/m/app.Spawn",
+		}},
+		{"example.com/m/trace.Timer.Start", []string{
+			"example.com/m/app.Time",
+		}},
+		// unqualified calls in the declaring package
+		{"example.com/m/trace.Start", []string{
+			"example.com/m/trace.Begin",
+		}},
+		// instantiations match their generic function
+		{"example.com/m/trace.Map", []string{
+			"example.com/m/app.Convert",
+		}},
+		{"Start", []string{
+			"(*example.com/m/app.Server).Handle",
+			"example.com/m/app.Spawn",
+			"example.com/m/app.Time",
+			"example.com/m/trace.Begin",
+		}},
+		// the initializer of started is not a function
+		{"example.com/m/app.run", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, fd := range a.RootFunctions(test.label) {
+			got = append(got, fd.Id)
+		}
+		sort.Strings(got)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: roots are %v, want %v", test.label, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: roots are %v, want %v", test.label, got, test.want)
+				break
+			}
+		}
+	}
+
+	var names []string
+	fds := a.RootFunctionsFunc(func(fn *types.Func) bool {
+		names = append(names, FuncQualifiedName(fn))
+		return fn.Name() == "Map"
+	})
+	if len(fds) != 1 || fds[0].DeclType != "func() int" {
+		t.Errorf("roots calling Map are %v", fds)
+	}
+	for _, name := range []string{"example.com/m/trace.Tracer.Start", "example.com/m/trace.Timer.Start"} {
+		var ok bool
+		for _, n := range names {
+			ok = ok || n == name
+		}
+		if !ok {
+			t.Errorf("no callee named %s in %v", name, names)
+		}
+	}
+}
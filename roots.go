@@ -0,0 +1,231 @@
+package goretriever
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// RootReason tells why a function is an entry point.
+type RootReason int
+
+const (
+	// RootMain is the main function of a main package.
+	RootMain RootReason = iota + 1
+	// RootInit is a package initializer or an init function.
+	RootInit
+	// RootTest is a TestXxx function.
+	RootTest
+	// RootBenchmark is a BenchmarkXxx function.
+	RootBenchmark
+	// RootFuzz is a FuzzXxx function.
+	RootFuzz
+	// RootExample is an ExampleXxx function.
+	RootExample
+	// RootHTTPHandler is registered with net/http.
+	RootHTTPHandler
+	// RootGoroutine is started by a go statement.
+	RootGoroutine
+	// RootExported is part of the exported API.
+	RootExported
+)
+
+func (r RootReason) String() string {
+	switch r {
+	case RootMain:
+		return "main"
+	case RootInit:
+		return "init"
+	case RootTest:
+		return "test"
+	case RootBenchmark:
+		return "benchmark"
+	case RootFuzz:
+		return "fuzz"
+	case RootExample:
+		return "example"
+	case RootHTTPHandler:
+		return "http handler"
+	case RootGoroutine:
+		return "goroutine"
+	case RootExported:
+		return "exported"
+	}
+	return fmt.Sprintf("RootReason(%d)", int(r))
+}
+
+// Root is an entry point with the reasons it was chosen.
+type Root struct {
+	Node    *CallNode
+	Reasons []RootReason
+}
+
+// Has reports whether reason is one of the reasons of r.
+func (r *Root) Has(reason RootReason) bool {
+	for _, rr := range r.Reasons {
+		if rr == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// rootSet collects roots and their reasons.
+type rootSet map[*CallNode]map[RootReason]bool
+
+func (s rootSet) add(n *CallNode, reason RootReason) {
+	if n == nil {
+		return
+	}
+	if s[n] == nil {
+		s[n] = make(map[RootReason]bool)
+	}
+	s[n][reason] = true
+}
+
+// roots returns the roots ordered by id, with ordered reasons.
+func (s rootSet) roots() []*Root {
+	roots := make([]*Root, 0, len(s))
+	for n, reasons := range s {
+		r := &Root{Node: n}
+		for reason := range reasons {
+			r.Reasons = append(r.Reasons, reason)
+		}
+		sort.Slice(r.Reasons, func(i, j int) bool { return r.Reasons[i] < r.Reasons[j] })
+		roots = append(roots, r)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Node.ID < roots[j].Node.ID })
+	return roots
+}
+
+// InferRootFunctions infers the entry points of the loaded packages from
+// their call graph and their syntax. On top of InferRootFunctionsFromGraph,
+// the functions, closures and handlers registered with net/http are found.
+func (a *Analyzer) InferRootFunctions() []*Root {
+	return a.InferRoots(a.BuildCallGraph())
+}
+
+// InferRoots is InferRootFunctions on a call graph of the loaded packages
+// built beforehand.
+func (a *Analyzer) InferRoots(callGraph *CallGraph) []*Root {
+	set := make(rootSet)
+	inferGraphRoots(callGraph, set)
+
+	closures := make(map[token.Position]*CallNode)
+	for _, n := range callGraph.Nodes() {
+		if n.Parent != nil {
+			closures[n.Pos] = n
+		}
+	}
+	handler := func(pkg *types.Info, expr ast.Expr) *CallNode {
+		switch expr := ast.Unparen(expr).(type) {
+		case *ast.FuncLit:
+			return closures[a.Fset.Position(expr.Pos())]
+		case *ast.Ident:
+			if fn, ok := pkg.Uses[expr].(*types.Func); ok {
+				return callGraph.NodeOf(fn)
+			}
+		case *ast.SelectorExpr:
+			if fn, ok := pkg.Uses[expr.Sel].(*types.Func); ok {
+				return callGraph.NodeOf(fn)
+			}
+		}
+		return nil
+	}
+
+	for _, pkg := range a.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				fn, ok := typeutil.Callee(pkg.TypesInfo, call).(*types.Func)
+				if !ok {
+					return true
+				}
+
+				switch FuncID(fn) {
+				case "net/http.HandleFunc", "(*net/http.ServeMux).HandleFunc":
+					set.add(handler(pkg.TypesInfo, call.Args[1]), RootHTTPHandler)
+
+				case "net/http.Handle", "(*net/http.ServeMux).Handle":
+					arg := ast.Unparen(call.Args[1])
+					// http.HandlerFunc(f) is a conversion
+					if conv, ok := arg.(*ast.CallExpr); ok && len(conv.Args) == 1 {
+						if tv, ok := pkg.TypesInfo.Types[conv.Fun]; ok && tv.IsType() {
+							set.add(handler(pkg.TypesInfo, conv.Args[0]), RootHTTPHandler)
+							return true
+						}
+					}
+					t := pkg.TypesInfo.TypeOf(arg)
+					if t == nil || types.IsInterface(t) {
+						return true
+					}
+					obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "ServeHTTP")
+					if m, ok := obj.(*types.Func); ok {
+						set.add(callGraph.NodeOf(m), RootHTTPHandler)
+					}
+				}
+				return true
+			})
+		}
+	}
+	return set.roots()
+}
+
+// InferRootFunctionsFromGraph infers the entry points of the packages
+// declaring functions in callGraph: main functions, init functions,
+// tests, benchmarks, fuzz tests and examples, goroutine targets, and
+// the exported API of non main packages. Goroutine targets are the
+// callees of go call sites.
+func InferRootFunctionsFromGraph(callGraph *CallGraph) []*Root {
+	set := make(rootSet)
+	inferGraphRoots(callGraph, set)
+	return set.roots()
+}
+
+func inferGraphRoots(callGraph *CallGraph, set rootSet) {
+	internal := make(map[string]bool)
+	for _, n := range callGraph.Nodes() {
+		if n.Pos.IsValid() {
+			internal[n.Pkg] = true
+		}
+	}
+
+	for _, n := range callGraph.Nodes() {
+		if !internal[n.Pkg] {
+			continue
+		}
+
+		switch {
+		case isMainFunc(n):
+			set.add(n, RootMain)
+		case isInitFunc(n):
+			set.add(n, RootInit)
+		}
+		if reason := testReason(n); reason != 0 {
+			set.add(n, reason)
+		}
+		if n.Func != nil && n.Pos.IsValid() && isExportedAPI(n.Func) &&
+			n.Func.Pkg().Name() != "main" && !strings.HasSuffix(n.Pos.Filename, "_test.go") {
+			set.add(n, RootExported)
+		}
+
+		for _, e := range n.Out {
+			for _, site := range e.Sites {
+				if site.Kind == CallGo && internal[e.Callee.Pkg] {
+					set.add(e.Callee, RootGoroutine)
+				}
+			}
+		}
+	}
+}
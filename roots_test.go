@@ -0,0 +1,128 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInferRoots(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"cmd/app/main.go": `package main
+
+import "example.com/m/api"
+
+func init() {}
+
+func worker() {}
+
+func Exported() {}
+
+func main() {
+	go worker()
+	api.Serve()
+}
+`,
+		"api/api.go": `package api
+
+import "net/http"
+
+type Health struct{}
+
+func (Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+type Client struct{}
+
+func (c *Client) Do() {}
+
+type client struct{}
+
+func (c *client) Do() {}
+
+func list(w http.ResponseWriter, r *http.Request) {}
+
+func get(w http.ResponseWriter, r *http.Request) {}
+
+func helper() {}
+
+func Serve() {
+	mux := http.NewServeMux()
+	mux.Handle("/health", Health{})
+	mux.Handle("/list", http.HandlerFunc(list))
+	http.HandleFunc("/get", get)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { helper() })
+}
+`,
+		"api/api_test.go": `package api
+
+import "testing"
+
+func TestServe(t *testing.T) {}
+
+func BenchmarkServe(b *testing.B) {}
+
+func FuzzServe(f *testing.F) {}
+
+func Example() {}
+
+func Testable(t *testing.T) {}
+
+func TestWrongSignature() {}
+`,
+	})
+
+	// net/http and testing are loaded from source by the SSA algorithms
+	a, err := NewAnalyzer(dir, []string{"./..."}, nil, WithAlgorithm(AlgorithmStatic), WithTests())
+	if err != nil {
+		t.Fatal(err)
+	}
+	callGraph := a.BuildCallGraph()
+
+	format := func(roots []*Root) string {
+		var lines []string
+		for _, r := range roots {
+			id := strings.ReplaceAll(r.Node.ID, "example.com/m/", "")
+			lines = append(lines, fmt.Sprintf("%s %v", id, r.Reasons))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	graphRoots := strings.Join([]string{
+		"(*api.Client).Do [exported]",
+		"(api.Health).ServeHTTP [exported]",
+		"api.BenchmarkServe [benchmark]",
+		"api.Example [example]",
+		"api.FuzzServe [fuzz]",
+		"api.Serve [exported]",
+		"api.TestServe [test]",
+		"api.init [init]",
+		"cmd/app.init [init]",
+		"cmd/app.init#1 [init]",
+		"cmd/app.main [main]",
+		"cmd/app.worker [goroutine]",
+	}, "\n")
+	if got := format(InferRootFunctionsFromGraph(callGraph)); got != graphRoots {
+		t.Errorf("roots of the graph are\n%s\nwant\n%s", got, graphRoots)
+	}
+
+	roots := strings.Join([]string{
+		"(*api.Client).Do [exported]",
+		"(api.Health).ServeHTTP [http handler exported]",
+		"api.BenchmarkServe [benchmark]",
+		"api.Example [example]",
+		"api.FuzzServe [fuzz]",
+		"api.Serve [exported]",
+		"api.Serve$1 [http handler]",
+		"api.TestServe [test]",
+		"api.get [http handler]",
+		"api.init [init]",
+		"api.list [http handler]",
+		"cmd/app.init [init]",
+		"cmd/app.init#1 [init]",
+		"cmd/app.main [main]",
+		"cmd/app.worker [goroutine]",
+	}, "\n")
+	if got := format(a.InferRoots(callGraph)); got != roots {
+		t.Errorf("roots are\n%s\nwant\n%s", got, roots)
+	}
+}
@@ -0,0 +1,176 @@
+package goretriever
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SCCs returns the strongly connected components of the graph with
+// Tarjan's algorithm, callees before their callers. The nodes of every
+// component are ordered by id.
+func (g *CallGraph) SCCs() [][]*CallNode {
+	var (
+		index   = make(map[*CallNode]int)
+		lowlink = make(map[*CallNode]int)
+		onStack = make(map[*CallNode]bool)
+		stack   []*CallNode
+		sccs    [][]*CallNode
+		next    int
+	)
+
+	// frame is a node being visited with the index of its next edge,
+	// the recursion is explicit so that deep graphs do not overflow.
+	type frame struct {
+		node *CallNode
+		edge int
+	}
+
+	for _, root := range g.Nodes() {
+		if _, ok := index[root]; ok {
+			continue
+		}
+
+		frames := []*frame{{node: root}}
+		index[root], lowlink[root] = next, next
+		next++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(frames) > 0 {
+			f := frames[len(frames)-1]
+			n := f.node
+
+			if f.edge < len(n.Out) {
+				callee := n.Out[f.edge].Callee
+				f.edge++
+				if _, ok := index[callee]; !ok {
+					index[callee], lowlink[callee] = next, next
+					next++
+					stack = append(stack, callee)
+					onStack[callee] = true
+					frames = append(frames, &frame{node: callee})
+				} else if onStack[callee] && index[callee] < lowlink[n] {
+					lowlink[n] = index[callee]
+				}
+				continue
+			}
+
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				if lowlink[n] < lowlink[parent] {
+					lowlink[parent] = lowlink[n]
+				}
+			}
+			if lowlink[n] != index[n] {
+				continue
+			}
+
+			var scc []*CallNode
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == n {
+					break
+				}
+			}
+			sort.Slice(scc, func(i, j int) bool { return scc[i].ID < scc[j].ID })
+			sccs = append(sccs, scc)
+		}
+	}
+	return sccs
+}
+
+// RecursionGroup is a set of functions calling each other, or a single
+// function calling itself.
+type RecursionGroup struct {
+	// Nodes are ordered by id.
+	Nodes []*CallNode
+	// Edges are the calls between the functions of the group, each of
+	// them closes a cycle. Their sites tell where the calls are made.
+	Edges []*CallEdge
+}
+
+// Direct reports whether the group is a single function calling itself.
+func (r *RecursionGroup) Direct() bool {
+	return len(r.Nodes) == 1
+}
+
+// RecursionReport lists the recursive groups of a call graph.
+type RecursionReport struct {
+	// Groups are ordered by the id of their first node.
+	Groups []*RecursionGroup
+}
+
+// Recursion returns the directly and mutually recursive functions of
+// the graph. Cycles through dynamic edges are included.
+func (g *CallGraph) Recursion() *RecursionReport {
+	report := &RecursionReport{}
+
+	for _, scc := range g.SCCs() {
+		members := make(map[*CallNode]bool, len(scc))
+		for _, n := range scc {
+			members[n] = true
+		}
+
+		group := &RecursionGroup{Nodes: scc}
+		for _, n := range scc {
+			for _, e := range n.Out {
+				if members[e.Callee] {
+					group.Edges = append(group.Edges, e)
+				}
+			}
+		}
+		if len(group.Edges) == 0 {
+			// a single function which does not call itself
+			continue
+		}
+		sort.Slice(group.Edges, func(i, j int) bool {
+			if group.Edges[i].Caller.ID != group.Edges[j].Caller.ID {
+				return group.Edges[i].Caller.ID < group.Edges[j].Caller.ID
+			}
+			return group.Edges[i].Callee.ID < group.Edges[j].Callee.ID
+		})
+		report.Groups = append(report.Groups, group)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Nodes[0].ID < report.Groups[j].Nodes[0].ID
+	})
+	return report
+}
+
+// String renders the report with one block per group, stable enough to
+// be compared between runs.
+func (r *RecursionReport) String() string {
+	b := &strings.Builder{}
+	for _, group := range r.Groups {
+		if group.Direct() {
+			fmt.Fprintf(b, "direct recursion: %s\n", group.Nodes[0].ID)
+		} else {
+			ids := make([]string, len(group.Nodes))
+			for i, n := range group.Nodes {
+				ids[i] = n.ID
+			}
+			fmt.Fprintf(b, "mutual recursion: %s\n", strings.Join(ids, ", "))
+		}
+
+		for _, e := range group.Edges {
+			kind := "calls"
+			if e.Dynamic {
+				kind = "may call"
+			}
+			if len(e.Sites) == 0 {
+				fmt.Fprintf(b, "\t%s %s %s\n", e.Caller.ID, kind, e.Callee.ID)
+				continue
+			}
+			for _, site := range e.Sites {
+				fmt.Fprintf(b, "\t%s: %s %s %s\n", site.Pos, e.Caller.ID, kind, e.Callee.ID)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package goretriever
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSCCs(t *testing.T) {
+	g := NewCallGraph()
+	a, b, c, d := g.AddNode("a"), g.AddNode("b"), g.AddNode("c"), g.AddNode("d")
+	g.AddEdge(a, b)
+	g.AddEdge(b, c)
+	g.AddEdge(c, b)
+	g.AddEdge(c, d)
+
+	var got []string
+	for _, scc := range g.SCCs() {
+		var ids []string
+		for _, n := range scc {
+			ids = append(ids, n.ID)
+		}
+		got = append(got, strings.Join(ids, ","))
+	}
+	// callees before their callers
+	if want := "d b,c a"; strings.Join(got, " ") != want {
+		t.Errorf("components are %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestSCCsDeepGraph(t *testing.T) {
+	g := NewCallGraph()
+	const depth = 100000
+	prev := g.AddNode("f0")
+	for i := 1; i < depth; i++ {
+		n := g.AddNode(fmt.Sprintf("f%d", i))
+		g.AddEdge(prev, n)
+		prev = n
+	}
+	g.AddEdge(prev, g.Node("f0"))
+
+	sccs := g.SCCs()
+	if len(sccs) != 1 || len(sccs[0]) != depth {
+		t.Errorf("got %d components, want one of %d functions", len(sccs), depth)
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"tree/tree.go": `package tree
+
+func Fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * Fact(n-1)
+}
+
+func Even(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return Odd(n - 1)
+}
+
+func Odd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return Even(n - 1)
+}
+
+type Walker interface{ Walk() }
+
+type Node struct{ Children []Walker }
+
+func (n *Node) Walk() {
+	for _, c := range n.Children {
+		c.Walk()
+	}
+}
+
+func Sum(xs []int) int { return len(xs) }
+`,
+	})
+
+	graph, err := BuildCallGraph(dir, []string{"./..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "tree", "tree.go")
+	report := graph.Recursion()
+	got := strings.ReplaceAll(report.String(), file, "tree.go")
+	want := `mutual recursion: (*example.com/m/tree.Node).Walk, (example.com/m/tree.Walker).Walk
+	tree.go:30:9: (*example.com/m/tree.Node).Walk calls (example.com/m/tree.Walker).Walk
+	(example.com/m/tree.Walker).Walk may call (*example.com/m/tree.Node).Walk
+mutual recursion: example.com/m/tree.Even, example.com/m/tree.Odd
+	tree.go:14:12: example.com/m/tree.Even calls example.com/m/tree.Odd
+	tree.go:21:13: example.com/m/tree.Odd calls example.com/m/tree.Even
+direct recursion: example.com/m/tree.Fact
+	tree.go:7:17: example.com/m/tree.Fact calls example.com/m/tree.Fact
+`
+	if got != want {
+		t.Errorf("report is\n%s\nwant\n%s", got, want)
+	}
+	if len(report.Groups) != 3 || !report.Groups[2].Direct() || report.Groups[1].Direct() {
+		t.Errorf("groups are %+v", report.Groups)
+	}
+}
@@ -0,0 +1,343 @@
+package goretriever
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// ssaConverter maps the functions of an SSA call graph onto the nodes
+// of a CallGraph, using the same ids as the AST algorithm.
+type ssaConverter struct {
+	a         *Analyzer
+	prog      *ssa.Program
+	pkgs      []*ssa.Package
+	funcs     []*ssa.Function
+	callGraph *CallGraph
+	loaded    map[string]bool
+	nodes     map[*ssa.Function]*CallNode
+	contents  map[string][]byte
+	sites     map[token.Pos]*CallSite
+}
+
+// newSSAConverter builds the SSA form of the loaded packages. Function
+// bodies are only built for the loaded packages, funcs lists their
+// functions ordered by name.
+func (a *Analyzer) newSSAConverter() *ssaConverter {
+	// loaded WithTests, the other packages import the package under test
+	// rather than its test variant, build its bodies too
+	initial := append([]*packages.Package(nil), a.Packages...)
+	loaded := make(map[string]bool)
+	for _, pkg := range a.Packages {
+		loaded[pkg.ID] = true
+	}
+	packages.Visit(a.Packages, nil, func(pkg *packages.Package) {
+		if !loaded[pkg.ID] && loaded[pkg.PkgPath+" ["+pkg.PkgPath+".test]"] {
+			loaded[pkg.ID] = true
+			initial = append(initial, pkg)
+		}
+	})
+
+	prog, ssaPkgs := ssautil.Packages(initial, ssa.InstantiateGenerics)
+
+	// dependencies not loaded from source have no syntax, create their
+	// members from the export data types
+	var createAll func(pkgs []*types.Package)
+	createAll = func(pkgs []*types.Package) {
+		for _, pkg := range pkgs {
+			if prog.Package(pkg) == nil {
+				prog.CreatePackage(pkg, nil, nil, true)
+				createAll(pkg.Imports())
+			}
+		}
+	}
+	for _, pkg := range ssaPkgs {
+		if pkg != nil {
+			createAll(pkg.Pkg.Imports())
+		}
+	}
+	prog.Build()
+
+	c := &ssaConverter{
+		a:         a,
+		prog:      prog,
+		pkgs:      ssaPkgs,
+		callGraph: NewCallGraph(),
+		loaded:    make(map[string]bool),
+		nodes:     make(map[*ssa.Function]*CallNode),
+		contents:  make(map[string][]byte),
+		sites:     make(map[token.Pos]*CallSite),
+	}
+	for _, pkg := range ssaPkgs {
+		if pkg != nil {
+			c.loaded[pkg.Pkg.Path()] = true
+		}
+	}
+	for _, pkg := range a.Packages {
+		x := &callSiteIndexer{
+			info:  pkg.TypesInfo,
+			fset:  a.Fset,
+			sites: c.sites,
+		}
+		for _, file := range pkg.Syntax {
+			x.content = c.content(a.Fset.Position(file.Pos()).Filename)
+			x.indexFile(file)
+		}
+	}
+
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != nil && c.loaded[fn.Pkg.Pkg.Path()] {
+			c.funcs = append(c.funcs, fn)
+		}
+	}
+	sort.Slice(c.funcs, func(i, j int) bool { return c.funcs[i].String() < c.funcs[j].String() })
+	return c
+}
+
+// buildSSACallGraph builds the call graph of the loaded packages with one
+// of the algorithms of golang.org/x/tools/go/callgraph. Calls made by
+// dependencies are not followed.
+func (a *Analyzer) buildSSACallGraph(alg Algorithm) *CallGraph {
+	var (
+		c       = a.newSSAConverter()
+		prog    = c.prog
+		ssaPkgs = c.pkgs
+		funcs   = c.funcs
+	)
+
+	var cg *callgraph.Graph
+	switch alg {
+	case AlgorithmStatic:
+		cg = static.CallGraph(prog)
+	case AlgorithmCHA:
+		cg = cha.CallGraph(prog)
+	case AlgorithmRTA:
+		roots := ssaRoots(prog, ssaPkgs)
+		if len(roots) == 0 {
+			// libraries have no entry point, start from all their functions
+			roots = funcs
+		}
+		if len(roots) == 0 {
+			return c.callGraph
+		}
+		cg = rta.Analyze(roots, true).CallGraph
+	case AlgorithmVTA:
+		cg = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	default:
+		return c.callGraph
+	}
+
+	// functions declared in the loaded packages are nodes even when
+	// they are never called
+	for _, fn := range funcs {
+		if fn.Synthetic == "" {
+			c.node(fn)
+		}
+	}
+
+	callgraph.GraphVisitEdges(cg, func(edge *callgraph.Edge) error {
+		caller, callee := edge.Caller.Func, edge.Callee.Func
+		if caller == nil || callee == nil {
+			return nil
+		}
+		if !c.loaded[ssaPkgPath(caller)] {
+			return nil
+		}
+		from, to := c.node(caller), c.node(callee)
+		if from == to && caller.Synthetic != "" {
+			// wrapper calling the method it wraps
+			return nil
+		}
+		if edge.Site == nil {
+			c.callGraph.AddEdge(from, to)
+			return nil
+		}
+
+		var (
+			common = edge.Site.Common()
+			site   = c.sites[edge.Site.Pos()]
+		)
+		switch {
+		case common.IsInvoke():
+			// go through the interface method like the AST algorithm
+			method := c.callGraph.AddFunc(common.Method)
+			c.callGraph.AddEdge(from, method).addSite(site)
+			c.callGraph.AddDynamicEdge(method, to)
+		case common.StaticCallee() == nil:
+			c.callGraph.AddDynamicEdge(from, to).addSite(site)
+		default:
+			c.callGraph.AddEdge(from, to).addSite(site)
+		}
+		return nil
+	})
+
+	for _, e := range c.callGraph.Edges() {
+		sort.Slice(e.Sites, func(i, j int) bool {
+			if e.Sites[i].Pos.Filename != e.Sites[j].Pos.Filename {
+				return e.Sites[i].Pos.Filename < e.Sites[j].Pos.Filename
+			}
+			return e.Sites[i].Pos.Offset < e.Sites[j].Pos.Offset
+		})
+	}
+	return c.callGraph
+}
+
+// ssaRoots returns the main and init functions of the main packages, and
+// the test functions with the init function of their packages.
+func ssaRoots(prog *ssa.Program, pkgs []*ssa.Package) []*ssa.Function {
+	var roots []*ssa.Function
+	for _, pkg := range pkgs {
+		if pkg == nil {
+			continue
+		}
+
+		var tests []*ssa.Function
+		for _, member := range pkg.Members {
+			fn, ok := member.(*ssa.Function)
+			if !ok || !strings.HasSuffix(prog.Fset.Position(fn.Pos()).Filename, "_test.go") {
+				continue
+			}
+			for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+				if testName(fn.Name(), prefix) {
+					tests = append(tests, fn)
+					break
+				}
+			}
+		}
+		sort.Slice(tests, func(i, j int) bool { return tests[i].Name() < tests[j].Name() })
+		roots = append(roots, tests...)
+
+		if pkg.Pkg.Name() == "main" {
+			roots = append(roots, pkg.Func("main"))
+		}
+		if pkg.Pkg.Name() == "main" || len(tests) > 0 {
+			roots = append(roots, pkg.Func("init"))
+		}
+	}
+
+	var nonNil []*ssa.Function
+	for _, fn := range roots {
+		if fn != nil {
+			nonNil = append(nonNil, fn)
+		}
+	}
+	return nonNil
+}
+
+// ssaPkgPath returns the path of the package declaring fn, or "" for
+// shared wrappers.
+func ssaPkgPath(fn *ssa.Function) string {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	if fn.Pkg == nil {
+		return ""
+	}
+	return fn.Pkg.Pkg.Path()
+}
+
+// node returns the call graph node of fn, creating it when missing.
+func (c *ssaConverter) node(fn *ssa.Function) *CallNode {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	if n, ok := c.nodes[fn]; ok {
+		return n
+	}
+
+	var (
+		n      *CallNode
+		obj, _ = fn.Object().(*types.Func)
+		pkg    = ssaPkgPath(fn)
+	)
+
+	switch {
+	case fn.Parent() != nil:
+		parent := c.node(fn.Parent())
+		suffix := strings.TrimPrefix(fn.Name(), fn.Parent().Name())
+		n = c.callGraph.AddNode(parent.ID + suffix)
+		n.Pkg = parent.Pkg
+		n.Recv = parent.Recv
+		n.Name = parent.Name + suffix
+		n.Signature = fn.Signature.String()
+		n.Parent = parent
+		if c.loaded[parent.Pkg] {
+			c.callGraph.AddEdge(parent, n)
+		}
+
+	case fn.Synthetic == "package initializer":
+		n = c.callGraph.AddNode(pkg + ".init")
+		n.Pkg = pkg
+		n.Name = "init"
+		n.Signature = "func()"
+		n.Synthetic = true
+
+	case obj != nil && strings.HasPrefix(fn.Name(), "init#") && fn.Signature.Recv() == nil:
+		n = c.callGraph.addFuncAs(obj, pkg+"."+fn.Name())
+
+	case obj != nil:
+		n = c.callGraph.AddFunc(obj)
+
+	default:
+		n = c.callGraph.AddNode(fn.String())
+		n.Pkg = pkg
+		n.Name = fn.Name()
+		n.Signature = fn.Signature.String()
+		n.Synthetic = fn.Synthetic != ""
+	}
+	c.nodes[fn] = n
+
+	if c.loaded[n.Pkg] && !n.Pos.IsValid() && fn.Syntax() != nil {
+		c.locate(n, fn.Syntax())
+	}
+	return n
+}
+
+// locate sets the position and the code of n from its syntax.
+func (c *ssaConverter) locate(n *CallNode, syntax ast.Node) {
+	fset := c.a.Fset
+	n.Pos = fset.Position(syntax.Pos())
+
+	beg, end := n.Pos.Offset, fset.Position(syntax.End()).Offset
+	if decl, ok := syntax.(*ast.FuncDecl); ok {
+		var err error
+		if beg, end, err = getFuncDeclOffset(decl, fset); err != nil {
+			return
+		}
+	}
+
+	code, err := parseCode(bytes.NewReader(c.content(n.Pos.Filename)), int64(beg), int64(end))
+	if err == nil {
+		n.Code = code
+	}
+
+	switch syntax := syntax.(type) {
+	case *ast.FuncDecl:
+		n.Metrics = newMetrics(fset, syntax, syntax.Type, syntax.Body)
+	case *ast.FuncLit:
+		n.Metrics = newMetrics(fset, syntax, syntax.Type, syntax.Body)
+	}
+}
+
+// content returns the content of filename, read once.
+func (c *ssaConverter) content(filename string) []byte {
+	content, ok := c.contents[filename]
+	if !ok {
+		content, _ = os.ReadFile(filename)
+		c.contents[filename] = content
+	}
+	return content
+}
@@ -0,0 +1,93 @@
+package goretriever
+
+import "testing"
+
+const algorithmSource = `package main
+
+type Animal interface{ Sound() string }
+
+type Dog struct{}
+
+func (Dog) Sound() string { return "woof" }
+
+type Cat struct{}
+
+func (Cat) Sound() string { return "meow" }
+
+func speak(a Animal) string { return a.Sound() }
+
+func apply(f func() int) int { return f() }
+
+func one() int { return 1 }
+
+func main() {
+	speak(Dog{})
+	apply(one)
+}
+`
+
+func TestBuildCallGraphAlgorithms(t *testing.T) {
+	dir := writeModule(t, map[string]string{"app/main.go": algorithmSource})
+
+	const (
+		p      = "example.com/m/app."
+		sound  = "(example.com/m/app.Animal).Sound"
+		dog    = "(example.com/m/app.Dog).Sound"
+		cat    = "(example.com/m/app.Cat).Sound"
+		edge   = "->"
+		absent = false
+	)
+	tests := []struct {
+		alg   Algorithm
+		edges map[string]bool
+	}{
+		{AlgorithmStatic, map[string]bool{
+			p + "main" + edge + p + "speak": true,
+			p + "main" + edge + p + "apply": true,
+			p + "apply" + edge + p + "one":  absent,
+			sound + edge + dog:              absent,
+		}},
+		{AlgorithmCHA, map[string]bool{
+			p + "speak" + edge + sound:     true,
+			sound + edge + dog:             true,
+			sound + edge + cat:             true,
+			p + "apply" + edge + p + "one": true,
+		}},
+		{AlgorithmRTA, map[string]bool{
+			sound + edge + dog:             true,
+			sound + edge + cat:             absent,
+			p + "apply" + edge + p + "one": true,
+		}},
+		{AlgorithmVTA, map[string]bool{
+			sound + edge + dog:             true,
+			sound + edge + cat:             absent,
+			p + "apply" + edge + p + "one": true,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.alg.String(), func(t *testing.T) {
+			graph, err := BuildCallGraph(dir, []string{"./..."}, nil, WithAlgorithm(test.alg))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			edges := make(map[string]bool)
+			for _, e := range graph.Edges() {
+				edges[e.Caller.ID+edge+e.Callee.ID] = true
+			}
+			for e, want := range test.edges {
+				if edges[e] != want {
+					t.Errorf("edge %s present: %t, want %t", e, edges[e], want)
+				}
+			}
+
+			// declared functions have the ids of the AST algorithm
+			for _, id := range []string{p + "main", p + "one", dog, cat} {
+				if n := graph.Node(id); n == nil || n.Pos.Line == 0 {
+					t.Errorf("node %s is %+v, want a declared function", id, n)
+				}
+			}
+		})
+	}
+}
@@ -3,15 +3,73 @@ package goretriever
 import (
 	"go/ast"
 	"go/token"
+	"go/types"
 	"io"
+	"strings"
 )
 
 type Struct struct {
-	Name    string
-	Code    string
-	Methods map[string]*Function
-	Beg     int `json:"-"`
-	End     int `json:"-"`
+	Name      string
+	Code      string
+	Doc       string
+	File      string
+	Line      int
+	Interface bool
+	Fields    []*Field
+	Methods   map[string]*Function
+	Beg       int `json:"-"`
+	End       int `json:"-"`
+}
+
+// Field is a struct field, or a method of an interface. Embedded
+// fields have the name of their type.
+type Field struct {
+	Name     string
+	Type     string
+	Tag      string
+	Embedded bool
+}
+
+func newFieldsFromList(list *ast.FieldList) []*Field {
+	if list == nil {
+		return nil
+	}
+
+	var fields []*Field
+	for _, field := range list.List {
+		typ := types.ExprString(field.Type)
+
+		var tag string
+		if field.Tag != nil {
+			tag = field.Tag.Value
+		}
+
+		if len(field.Names) == 0 {
+			name := strings.TrimPrefix(typ, "*")
+			if i := strings.IndexAny(name, "["); i >= 0 {
+				name = name[:i]
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			fields = append(fields, &Field{
+				Name:     name,
+				Type:     typ,
+				Tag:      tag,
+				Embedded: true,
+			})
+			continue
+		}
+
+		for _, name := range field.Names {
+			fields = append(fields, &Field{
+				Name: name.Name,
+				Type: typ,
+				Tag:  tag,
+			})
+		}
+	}
+	return fields
 }
 
 func newStructFromDecl(reader io.ReaderAt, decl *ast.GenDecl, fileSet *token.FileSet) *Struct {
@@ -46,6 +104,21 @@ func newStructFromDecl(reader io.ReaderAt, decl *ast.GenDecl, fileSet *token.Fil
 		s.Code = code
 		s.Beg = beg
 		s.End = end
+
+		if decl.Doc != nil {
+			s.Doc = decl.Doc.Text()
+		} else if ts.Doc != nil {
+			s.Doc = ts.Doc.Text()
+		}
+		s.File, s.Line = getOffsetPosition(decl, beg, fileSet)
+
+		switch t := ts.Type.(type) {
+		case *ast.StructType:
+			s.Fields = newFieldsFromList(t.Fields)
+		case *ast.InterfaceType:
+			s.Interface = true
+			s.Fields = newFieldsFromList(t.Methods)
+		}
 		return s
 	}
 
@@ -0,0 +1,118 @@
+package goretriever
+
+import (
+	"sort"
+)
+
+// SymbolKind tells what kind of declaration a symbol stands for.
+type SymbolKind int
+
+const (
+	SymbolType SymbolKind = iota + 1
+	SymbolMethod
+	SymbolFunction
+	SymbolField
+	SymbolConst
+	SymbolVar
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolType:
+		return "type"
+	case SymbolMethod:
+		return "method"
+	case SymbolFunction:
+		return "func"
+	case SymbolField:
+		return "field"
+	case SymbolConst:
+		return "const"
+	case SymbolVar:
+		return "var"
+	}
+	return "unknown"
+}
+
+// Symbol is a flat view of a declaration of the Package model.
+type Symbol struct {
+	Kind    SymbolKind
+	Package string
+	Path    string // import path of the package, when known
+	Dir     string
+	Name    string
+	Recv    string
+	Doc     string
+	File    string
+	Line    int
+	Code    string
+}
+
+// QualifiedName returns the name of the symbol prefixed with its
+// package and receiver, eg. pkg.Type.Method.
+func (s *Symbol) QualifiedName() string {
+	if s.Recv != "" {
+		return s.Package + "." + s.Recv + "." + s.Name
+	}
+	return s.Package + "." + s.Name
+}
+
+// Symbols flattens the types, methods and functions of the package,
+// ordered by kind, receiver and name.
+func (p *Package) Symbols() []*Symbol {
+	var symbols []*Symbol
+
+	for _, s := range p.Structs {
+		symbols = append(symbols, &Symbol{
+			Kind:    SymbolType,
+			Package: p.Name,
+			Path:    p.Path,
+			Dir:     p.Dir,
+			Name:    s.Name,
+			Doc:     s.Doc,
+			File:    s.File,
+			Line:    s.Line,
+			Code:    s.Code,
+		})
+		for _, m := range s.Methods {
+			symbols = append(symbols, &Symbol{
+				Kind:    SymbolMethod,
+				Package: p.Name,
+				Path:    p.Path,
+				Dir:     p.Dir,
+				Name:    m.Name,
+				Recv:    s.Name,
+				Doc:     m.Doc,
+				File:    m.File,
+				Line:    m.Line,
+				Code:    m.Code,
+			})
+		}
+	}
+
+	for _, f := range p.Functions {
+		symbols = append(symbols, &Symbol{
+			Kind:    SymbolFunction,
+			Package: p.Name,
+			Path:    p.Path,
+			Dir:     p.Dir,
+			Name:    f.Name,
+			Doc:     f.Doc,
+			File:    f.File,
+			Line:    f.Line,
+			Code:    f.Code,
+		})
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := symbols[i], symbols[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Recv != b.Recv {
+			return a.Recv < b.Recv
+		}
+		return a.Name < b.Name
+	})
+	return symbols
+}
@@ -0,0 +1,310 @@
+package goretriever
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MatchKind tells how a query matched a symbol, from the best match to
+// the worst.
+type MatchKind int
+
+const (
+	// MatchExact is the name or qualified name itself.
+	MatchExact MatchKind = iota
+	// MatchFold is the name ignoring case.
+	MatchFold
+	// MatchPrefix is a prefix of the name, case is ignored with a lower
+	// score.
+	MatchPrefix
+	// MatchSubstring is a part of the name, case ignored.
+	MatchSubstring
+	// MatchFuzzy is a subsequence of the name, or the name with a few
+	// typos, case ignored.
+	MatchFuzzy
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case MatchExact:
+		return "exact"
+	case MatchFold:
+		return "fold"
+	case MatchPrefix:
+		return "prefix"
+	case MatchSubstring:
+		return "substring"
+	case MatchFuzzy:
+		return "fuzzy"
+	}
+	return fmt.Sprintf("MatchKind(%d)", int(k))
+}
+
+// SearchResult is a symbol found by a search.
+type SearchResult struct {
+	Symbol *Symbol
+	Match  MatchKind
+	// Score is in ]0, 1], 1 for exact matches.
+	Score float64
+}
+
+// SearchOptions restricts the results of a search.
+type SearchOptions struct {
+	// Kinds keeps the symbols of the given kinds, all when empty.
+	Kinds []SymbolKind
+	// MinScore drops the results scoring less, eg. 0.5 keeps exact,
+	// prefix and substring matches only.
+	MinScore float64
+	// Limit is the maximum number of results, 0 means no limit.
+	Limit int
+}
+
+// Index looks up the symbols of parsed packages by name.
+type Index struct {
+	// Symbols are ordered by package, then like Package.Symbols.
+	Symbols []*Symbol
+
+	byName map[string][]*Symbol
+}
+
+// NewIndex indexes the symbols of pkgs.
+func NewIndex(pkgs []*Package) *Index {
+	sorted := append([]*Package(nil), pkgs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Dir < sorted[j].Dir
+	})
+
+	ix := &Index{byName: make(map[string][]*Symbol)}
+	for _, p := range sorted {
+		for _, s := range p.Symbols() {
+			ix.Symbols = append(ix.Symbols, s)
+			ix.byName[s.Name] = append(ix.byName[s.Name], s)
+		}
+	}
+	return ix
+}
+
+// symbolNames returns the names a symbol may be looked up with: its
+// name, Recv.Name for methods and its qualified name.
+func symbolNames(s *Symbol) []string {
+	names := []string{s.Name}
+	if s.Recv != "" {
+		names = append(names, s.Recv+"."+s.Name)
+	}
+	return append(names, s.QualifiedName())
+}
+
+// Lookup returns the symbols named name, or whose Recv.Name or qualified
+// name is name.
+func (ix *Index) Lookup(name string) []*Symbol {
+	if !strings.Contains(name, ".") {
+		return ix.byName[name]
+	}
+	return ix.filter(func(s *Symbol) bool {
+		for _, n := range symbolNames(s)[1:] {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// LookupFold is Lookup ignoring case.
+func (ix *Index) LookupFold(name string) []*Symbol {
+	return ix.filter(func(s *Symbol) bool {
+		for _, n := range symbolNames(s) {
+			if strings.EqualFold(n, name) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Prefix returns the symbols whose name, Recv.Name or qualified name
+// starts with prefix.
+func (ix *Index) Prefix(prefix string) []*Symbol {
+	return ix.filter(func(s *Symbol) bool {
+		for _, n := range symbolNames(s) {
+			if strings.HasPrefix(n, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// OfKind returns the symbols of the given kind.
+func (ix *Index) OfKind(kind SymbolKind) []*Symbol {
+	return ix.filter(func(s *Symbol) bool { return s.Kind == kind })
+}
+
+// MethodsOf returns the methods of the type named typeName, or
+// pkg.Type to choose between types of different packages.
+func (ix *Index) MethodsOf(typeName string) []*Symbol {
+	pkg, name, qualified := strings.Cut(typeName, ".")
+	if !qualified {
+		name = pkg
+	}
+	return ix.filter(func(s *Symbol) bool {
+		return s.Kind == SymbolMethod && s.Recv == name && (!qualified || s.Package == pkg)
+	})
+}
+
+func (ix *Index) filter(keep func(*Symbol) bool) []*Symbol {
+	var symbols []*Symbol
+	for _, s := range ix.Symbols {
+		if keep(s) {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// Search returns the symbols matching query, best first. Queries with a
+// dot are matched against Recv.Name and qualified names, the others
+// against names. Results with the same score are ordered by kind and
+// qualified name.
+func (ix *Index) Search(query string, opts *SearchOptions) []*SearchResult {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	if query == "" {
+		return nil
+	}
+
+	var results []*SearchResult
+	for _, s := range ix.Symbols {
+		if len(opts.Kinds) > 0 {
+			var ok bool
+			for _, kind := range opts.Kinds {
+				ok = ok || s.Kind == kind
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		names := symbolNames(s)
+		if strings.Contains(query, ".") {
+			names = names[1:]
+		} else {
+			names = names[:1]
+		}
+
+		var best *SearchResult
+		for _, name := range names {
+			match, score, ok := matchName(query, name)
+			if ok && score >= opts.MinScore && (best == nil || score > best.Score) {
+				best = &SearchResult{Symbol: s, Match: match, Score: score}
+			}
+		}
+		if best != nil {
+			results = append(results, best)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Symbol.Kind != b.Symbol.Kind {
+			return a.Symbol.Kind < b.Symbol.Kind
+		}
+		return a.Symbol.QualifiedName() < b.Symbol.QualifiedName()
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+// matchName scores how well query matches name. Within a match kind,
+// the closer the lengths of query and name, the higher the score.
+func matchName(query, name string) (MatchKind, float64, bool) {
+	var (
+		lq, ln = strings.ToLower(query), strings.ToLower(name)
+		ratio  = float64(len(query)) / float64(len(name))
+	)
+	if ratio > 1 {
+		ratio = 1
+	}
+
+	switch {
+	case query == name:
+		return MatchExact, 1, true
+	case lq == ln:
+		return MatchFold, 0.95, true
+	case strings.HasPrefix(name, query):
+		return MatchPrefix, 0.8 + 0.1*ratio, true
+	case strings.HasPrefix(ln, lq):
+		return MatchPrefix, 0.7 + 0.1*ratio, true
+	case strings.Contains(ln, lq):
+		return MatchSubstring, 0.5 + 0.1*ratio, true
+	}
+
+	if quality, ok := subsequence(lq, name); ok {
+		return MatchFuzzy, 0.2 + 0.2*quality, true
+	}
+	if d := levenshtein(lq, ln); d <= max(1, len(lq)/4) {
+		return MatchFuzzy, 0.2 * (1 - float64(d)/float64(max(len(lq), len(ln)))), true
+	}
+	return 0, 0, false
+}
+
+// subsequence reports whether the lower case query is a subsequence of
+// name, with a quality in [0, 1] favoring matches at the start of words,
+// as in hc for HandleConn or handle_conn.
+func subsequence(query, name string) (float64, bool) {
+	var (
+		runes      = []rune(name)
+		matched    int
+		boundaries int
+		i          int
+	)
+	for _, q := range query {
+		for ; i < len(runes); i++ {
+			if unicode.ToLower(runes[i]) == q {
+				break
+			}
+		}
+		if i == len(runes) {
+			return 0, false
+		}
+		if i == 0 || unicode.IsUpper(runes[i]) || runes[i-1] == '_' || runes[i-1] == '.' {
+			boundaries++
+		}
+		matched++
+		i++
+	}
+	return float64(boundaries) / float64(matched), true
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
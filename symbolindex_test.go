@@ -0,0 +1,117 @@
+package goretriever
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestSymbolIndex(t *testing.T) *Index {
+	t.Helper()
+
+	var pkgs []*Package
+	for name, src := range map[string]string{
+		"server": `package server
+
+type Server struct{}
+
+func (s *Server) HandleConn() {}
+
+func (s *Server) Close() error { return nil }
+
+func NewServer() *Server { return &Server{} }
+
+func handle_conn() {}
+`,
+		"client": `package client
+
+type Client struct{}
+
+func (c *Client) Close() error { return nil }
+
+func Dial() *Client { return &Client{} }
+`,
+	} {
+		pkg, err := ParseString(name, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return NewIndex(pkgs)
+}
+
+func symbolIDs(symbols []*Symbol) string {
+	var ids []string
+	for _, s := range symbols {
+		ids = append(ids, s.QualifiedName())
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestSymbolIndexLookup(t *testing.T) {
+	ix := newTestSymbolIndex(t)
+
+	tests := []struct {
+		name string
+		got  []*Symbol
+		want string
+	}{
+		{"Lookup name", ix.Lookup("Close"), "client.Client.Close server.Server.Close"},
+		{"Lookup Recv.Name", ix.Lookup("Server.Close"), "server.Server.Close"},
+		{"Lookup qualified name", ix.Lookup("server.NewServer"), "server.NewServer"},
+		{"Lookup is case sensitive", ix.Lookup("close"), ""},
+		{"LookupFold name", ix.LookupFold("close"), "client.Client.Close server.Server.Close"},
+		{"LookupFold qualified name", ix.LookupFold("SERVER.server"), "server.Server"},
+		{"Prefix name", ix.Prefix("New"), "server.NewServer"},
+		{"Prefix Recv.Name", ix.Prefix("Server."), "server.Server.Close server.Server.HandleConn"},
+		{"Prefix qualified name", ix.Prefix("client."), "client.Client client.Client.Close client.Dial"},
+		{"OfKind", ix.OfKind(SymbolType), "client.Client server.Server"},
+		{"MethodsOf", ix.MethodsOf("Server"), "server.Server.Close server.Server.HandleConn"},
+		{"MethodsOf pkg.Type", ix.MethodsOf("client.Client"), "client.Client.Close"},
+		{"MethodsOf of another package", ix.MethodsOf("client.Server"), ""},
+	}
+	for _, test := range tests {
+		if got := symbolIDs(test.got); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if dial := ix.Lookup("Dial"); len(dial) != 1 || dial[0].Line != 7 || !strings.Contains(dial[0].Code, "return &Client{}") {
+		t.Errorf("Dial is %+v", dial)
+	}
+}
+
+func TestSymbolIndexSearch(t *testing.T) {
+	ix := newTestSymbolIndex(t)
+
+	format := func(results []*SearchResult) string {
+		var s []string
+		for _, r := range results {
+			s = append(s, fmt.Sprintf("%s:%s:%.3f", r.Symbol.QualifiedName(), r.Match, r.Score))
+		}
+		return strings.Join(s, " ")
+	}
+
+	tests := []struct {
+		query string
+		opts  *SearchOptions
+		want  string
+	}{
+		{"Server.Close", nil, "server.Server.Close:exact:1.000"},
+		{"server", nil, "server.Server:fold:0.950 server.NewServer:substring:0.567"},
+		{"Han", nil, "server.Server.HandleConn:prefix:0.830 server.handle_conn:prefix:0.727"},
+		// word starts, a tie broken by kind
+		{"hc", nil, "server.Server.HandleConn:fuzzy:0.400 server.handle_conn:fuzzy:0.400"},
+		// a typo
+		{"Closse", nil, "client.Client.Close:fuzzy:0.167 server.Server.Close:fuzzy:0.167"},
+		{"Close", &SearchOptions{Kinds: []SymbolKind{SymbolMethod}, Limit: 1}, "client.Client.Close:exact:1.000"},
+		{"hc", &SearchOptions{MinScore: 0.5}, ""},
+		{"", nil, ""},
+	}
+	for _, test := range tests {
+		if got := format(ix.Search(test.query, test.opts)); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.query, got, test.want)
+		}
+	}
+}
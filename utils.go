@@ -22,7 +22,7 @@ func Min[T token.Pos](a, b T) T {
 }
 
 func parseCode(reader io.ReaderAt, beg, end int64) (string, error) {
-	if reader == nil {
+	if reader == nil || end < beg {
 		return "", errors.New("invalid input")
 	}
 
@@ -88,12 +88,12 @@ func getGenDeclOffset(decl *ast.GenDecl, fileSet *token.FileSet) (int, int, erro
 	return fileSet.Position(beg).Offset, fileSet.Position(end).Offset, nil
 }
 
-// Contains.
-func funcContains(a []*FuncDescriptor, x *FuncDescriptor) bool {
-	for _, n := range a {
-		if x.TypeHash() == n.TypeHash() {
-			return true
-		}
+// getOffsetPosition resolves the file name and line of an offset
+// inside the file containing node.
+func getOffsetPosition(node ast.Node, offset int, fileSet *token.FileSet) (string, int) {
+	file := fileSet.File(node.Pos())
+	if file == nil || offset < 0 || offset > file.Size() {
+		return "", 0
 	}
-	return false
+	return file.Name(), file.Line(file.Pos(offset))
 }